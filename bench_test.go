@@ -0,0 +1,141 @@
+package conf8n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Benchmarks of common operations on big synthetic configs (see README for running and comparing them)
+
+// Returns data of synthetic config: sections "s0"..."sN" with given count of entries "e0"..."eN"; every entry is
+// a map of scalars and a short list. Also has list "list" and map "map" of given size (for iteration) and
+// top-level key "flat.key", containing separator (for literal lookups)
+func syntheticData(sections, entries, size int) map[string]interface{} {
+	data := make(map[string]interface{}, sections+3)
+	for s := 0; s < sections; s++ {
+		section := make(map[string]interface{}, entries)
+		for e := 0; e < entries; e++ {
+			section[fmt.Sprint("e", e)] = map[string]interface{}{
+				"host":    fmt.Sprintf("host-%d-%d.local", s, e),
+				"port":    8000 + e,
+				"weight":  float64(e) / 10,
+				"enabled": e%2 == 0,
+				"tags":    []interface{}{"a", "b", fmt.Sprint("t", e)},
+			}
+		}
+		data[fmt.Sprint("s", s)] = section
+	}
+	list := make([]interface{}, size)
+	m := make(map[string]interface{}, size)
+	for i := 0; i < size; i++ {
+		list[i] = i
+		m[fmt.Sprint("k", i)] = i
+	}
+	data["list"], data["map"], data["flat.key"] = list, m, "literal"
+	return data
+}
+
+// Returns synthetic config (see syntheticData()), encoded as YAML or JSON
+func syntheticDoc(format string, sections, entries, size int) []byte {
+	var doc []byte
+	var err error
+	if format == "yaml" {
+		doc, err = yaml.Marshal(syntheticData(sections, entries, size))
+	} else {
+		doc, err = json.Marshal(syntheticData(sections, entries, size))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Returns synthetic config (see syntheticData()), loaded from YAML or JSON document
+func syntheticConfig(b *testing.B, format string, sections, entries, size int) *Config {
+	c, err := NewConfigFromReader(bytes.NewReader(syntheticDoc(format, sections, entries, size)), format)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func BenchmarkGetKinds(b *testing.B) {
+	keys := []struct{ name, key string }{
+		{"shallow", "s1"},
+		{"deep", "s1.e5.host"},
+		{"literal", "flat.key"},
+		{"slice element", "s1.e5.tags.2"},
+		{"miss", "s1.e5.missing"},
+	}
+	for _, format := range []string{"yaml", "json"} {
+		c := syntheticConfig(b, format, 10, 100, 10)
+		for _, k := range keys {
+			if k.name != "miss" && !c.Has(k.key) {
+				b.Fatalf("%s: key %s is not set", format, k.key)
+			}
+			b.Run(format+"/"+k.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					c.Get(k.key)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkIterate10k(b *testing.B) {
+	c := NewConfig(syntheticData(1, 1, 10000))
+	for _, key := range []string{"list", "map"} {
+		b.Run(key, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for it := c.Get(key).Iterate(); !it.Finished(); it.Next() {
+					it.Value()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMergeLarge(b *testing.B) {
+	base := syntheticData(20, 500, 1000)
+	other := NewConfig(syntheticData(20, 500, 1000))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		data, _ := deepCopy(base)
+		c := NewConfig(data.(map[string]interface{}))
+		b.StartTimer()
+		c.Merge(other, "")
+	}
+}
+
+func BenchmarkLoadLarge(b *testing.B) {
+	for _, format := range []string{"yaml", "json"} {
+		doc := syntheticDoc(format, 10, 500, 1000)
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(doc)))
+			for i := 0; i < b.N; i++ {
+				if _, err := NewConfigFromReader(bytes.NewReader(doc), format); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// JSON encoding of whole config (as Checksum() does it)
+func BenchmarkEncodeJSONLarge(b *testing.B) {
+	c := NewConfig(syntheticData(20, 500, 1000))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(jsonCompatible(c.tree())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package conf8n
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Cache of lookup results by exact keys. Cache is valid, while counter of changes of config data (shared by config,
+// its parents and sub-configs) has the same value, as when cache was filled
+type lookupCache struct {
+	hits    uint64
+	misses  uint64
+	changes uint64
+	mu      sync.Mutex
+	owner   *Config
+	size    int
+	values  map[string]cachedLookup
+}
+
+type cachedLookup struct {
+	v   interface{}
+	err error
+}
+
+// Enables caching of lookup results and returns config itself. Up to size results (including ones for keys,
+// that are not set) are kept by exact key strings, so repeated lookups of hot keys (like log level, checked
+// on every request) don't traverse the tree; when cache is full, arbitrary entry is evicted. Cache is dropped
+// on every change of config (Set(), Delete(), Merge(), ExpandEnv() and others), made through config itself,
+// its parents or sub-configs; changes of data, got from config as raw maps or slices, are not tracked.
+// Overrides (see PushOverrides()) and deprecated keys are applied to cached results as usual. Sub-configs
+// don't use cache of their parent, but can have their own. Non-positive size disables cache
+func (c *Config) Cache(size int) *Config {
+	c.cache = nil
+	if size > 0 {
+		// config may be created without NewConfig()
+		if c.changes == nil {
+			c.changes = new(uint64)
+		}
+		c.cache = &lookupCache{owner: c, size: size}
+	}
+	return c
+}
+
+// Returns numbers of lookups, served by cache (see Cache()) and missed it
+func (c *Config) CacheStats() (hits, misses uint64) {
+	if lc := c.cache; lc != nil && lc.owner == c {
+		return atomic.LoadUint64(&lc.hits), atomic.LoadUint64(&lc.misses)
+	}
+	return 0, 0
+}
+
+// Looks up key, using cache, if it is enabled
+func (c *Config) cachedLookup(key string) (interface{}, error) {
+	lc := c.cache
+	if lc == nil || lc.owner != c || c.changes == nil {
+		return c.lookupKey(key)
+	}
+	changes := atomic.LoadUint64(c.changes)
+	lc.mu.Lock()
+	res, found := lc.values[key]
+	found = found && lc.changes == changes
+	lc.mu.Unlock()
+	if found {
+		atomic.AddUint64(&lc.hits, 1)
+		return res.v, res.err
+	}
+	atomic.AddUint64(&lc.misses, 1)
+	res.v, res.err = c.lookupKey(key)
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	// result is not cached, if config was changed during the lookup
+	if atomic.LoadUint64(c.changes) != changes {
+		return res.v, res.err
+	}
+	if lc.values == nil || lc.changes != changes {
+		lc.values = make(map[string]cachedLookup, lc.size)
+		lc.changes = changes
+	}
+	if len(lc.values) >= lc.size {
+		for k := range lc.values {
+			delete(lc.values, k)
+			break
+		}
+	}
+	lc.values[key] = res
+	return res.v, res.err
+}
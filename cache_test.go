@@ -0,0 +1,115 @@
+package conf8n
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCacheIsNotStale(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"log": map[string]interface{}{"level": "info"}}).Cache(8)
+	sub := c.Get("log").Config()
+	other := NewConfig(map[string]interface{}{"log": map[string]interface{}{"level": "error"}})
+	steps := []struct {
+		set  func()
+		want string
+	}{
+		{nil, "info"},
+		{func() { c.Set("log.level", "debug") }, "debug"},
+		{func() { sub.Set("level", "warn") }, "warn"},
+		{func() { c.Delete("log.level") }, ""},
+		{func() { c.Merge(other, "") }, "error"},
+	}
+	for i, step := range steps {
+		if step.set != nil {
+			step.set()
+		}
+		// the second lookup is served by cache
+		for j := 0; j < 2; j++ {
+			if got := c.Get("log.level").String(); got != step.want {
+				t.Fatalf("step %d, lookup %d: got %q, want %q", i, j, got, step.want)
+			}
+		}
+	}
+	if hits, misses := c.CacheStats(); hits != uint64(len(steps)) || misses < uint64(len(steps)) {
+		t.Errorf("CacheStats(): got %d hits, %d misses; want %d hits and at least as many misses", hits, misses, len(steps))
+	}
+}
+
+func TestCacheOfSubConfig(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"log": map[string]interface{}{"level": "info"}})
+	sub := c.Get("log").Config().Cache(8)
+	if got := sub.Get("level").String(); got != "info" {
+		t.Fatalf("got %q, want info", got)
+	}
+	c.Set("log.level", "debug")
+	if got := sub.Get("level").String(); got != "debug" {
+		t.Errorf("after change of parent: got %q, want debug", got)
+	}
+}
+
+func TestCacheIsBounded(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"a": 1, "b": 2, "c": 3}).Cache(2)
+	for _, k := range []string{"a", "b", "c", "a", "b", "c"} {
+		c.Get(k)
+	}
+	if n := len(c.cache.values); n > 2 {
+		t.Errorf("cache holds %d values, want at most 2", n)
+	}
+}
+
+func TestZeroConfig(t *testing.T) {
+	var c Config
+	if err := c.Set("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	c.Cache(4)
+	if got := c.Get("a").Int(); got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+	c.Set("a", 2)
+	if got := c.Get("a").Int(); got != 2 {
+		t.Errorf("after Set(): got %d, want 2", got)
+	}
+}
+
+func TestCacheConcurrentAccess(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"log": map[string]interface{}{"level": "info"}}).Cache(4)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			c.Get("log.level")
+			c.Get("log.missing")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.Get("log.level")
+	}
+	<-done
+	hits, misses := c.CacheStats()
+	if hits+misses != 2100 {
+		t.Errorf("Got %d hits and %d misses, want 2100 lookups", hits, misses)
+	}
+}
+
+// Repeated lookups of hot key with and without cache (serial and from several goroutines)
+func BenchmarkCachedGet(b *testing.B) {
+	key := nestedKey(6)
+	for _, size := range []int{0, 16} {
+		c := NewConfig(nestedTree(6, 4)).Cache(size)
+		b.Run(fmt.Sprint("cache=", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.GetV(key)
+			}
+		})
+		b.Run(fmt.Sprint("cache=", size, ",parallel"), func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.GetV(key)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package conf8n
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Returns checksum of config data (hex-encoded SHA-256 of its canonical JSON form). Configs with equal data have
+// equal checksums regardless of their source format, formatting, comments and order of keys
+func (c *Config) Checksum() string {
+	data, err := json.Marshal(jsonCompatible(c.tree()))
+	if err != nil {
+		// values, that JSON can't represent (like NaN), are hashed in Go syntax
+		data = []byte(fmt.Sprintf("%#v", c.tree()))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,156 @@
+package conf8n
+
+type filterIterator struct {
+	it   Iterator
+	pred func(key string, v *ConfigValue) bool
+	i    int
+}
+
+type mapValuesIterator struct {
+	Iterator
+	fn func(*ConfigValue) *ConfigValue
+}
+
+// Returns iterator over items of given iterator, matching predicate. Items are checked lazily, while iterating;
+// Index() returns position of the item among matched ones
+func Filter(it Iterator, pred func(key string, v *ConfigValue) bool) Iterator {
+	f := &filterIterator{it: it, pred: pred}
+	f.skip()
+	return f
+}
+
+// Returns iterator over items of given iterator with values, replaced with results of fn (fn is called on every
+// Value() call). Keys and indices are not changed
+func MapValues(it Iterator, fn func(*ConfigValue) *ConfigValue) Iterator {
+	return &mapValuesIterator{it, fn}
+}
+
+// Skips items of wrapped iterator, that don't match predicate
+func (f *filterIterator) skip() {
+	for !f.it.Finished() && !f.pred(f.it.Key(), f.it.Value()) {
+		f.it.Next()
+	}
+}
+
+// See doc for Filter()
+func (f *filterIterator) Next() {
+	if f.it.Finished() {
+		return
+	}
+	f.it.Next()
+	f.i++
+	f.skip()
+}
+
+// See doc for Filter()
+func (f *filterIterator) Finished() bool {
+	return f.it.Finished()
+}
+
+// Returns position of current item among matched ones
+func (f *filterIterator) Index() int {
+	return f.i
+}
+
+// See doc for Filter()
+func (f *filterIterator) Key() string {
+	return f.it.Key()
+}
+
+// See doc for Filter()
+func (f *filterIterator) Value() *ConfigValue {
+	return f.it.Value()
+}
+
+// See doc for Filter()
+func (f *filterIterator) Section() (*Config, error) {
+	return iteratorSection(f)
+}
+
+// See doc for Filter()
+func (f *filterIterator) Reset() {
+	f.it.Reset()
+	f.i = 0
+	f.skip()
+}
+
+// See doc for MapValues()
+func (m *mapValuesIterator) Value() *ConfigValue {
+	if m.Finished() {
+		return m.Iterator.Value()
+	}
+	return m.fn(m.Iterator.Value())
+}
+
+// Returns mapped value as Config (see ListIterator.Section())
+func (m *mapValuesIterator) Section() (*Config, error) {
+	return iteratorSection(m)
+}
+
+type reverseIterator struct {
+	inner Iterator
+	list  *ListIterator
+	n     int
+}
+
+// Same as Iterate(), but items of slice are iterated from the last to the first one (Index() returns actual index
+// of the element), and keys of map are iterated in reverse sorted order
+func (v ConfigValue) IterateReverse() Iterator {
+	switch it := v.Iterate().(type) {
+	case *ListIterator:
+		return &reverseIterator{inner: it, list: it}
+	case *MapIterator:
+		return &reverseIterator{inner: it, list: it.ListIterator}
+	}
+	return &EmptyIterator{}
+}
+
+// Points wrapped iterator to current item
+func (r *reverseIterator) sync() Iterator {
+	if r.Finished() {
+		r.list.i = len(r.list.a)
+	} else {
+		r.list.i = len(r.list.a) - 1 - r.n
+	}
+	return r.inner
+}
+
+// See doc for IterateReverse()
+func (r *reverseIterator) Next() {
+	if !r.Finished() {
+		r.n++
+	}
+}
+
+// See doc for IterateReverse()
+func (r *reverseIterator) Finished() bool {
+	return r.n >= len(r.list.a)
+}
+
+// Returns actual index of current item; finished iterator gives count of items, as iterator of Iterate() does
+func (r *reverseIterator) Index() int {
+	return r.sync().Index()
+}
+
+// See doc for IterateReverse()
+func (r *reverseIterator) Key() string {
+	return r.sync().Key()
+}
+
+// See doc for IterateReverse()
+func (r *reverseIterator) Value() *ConfigValue {
+	return r.sync().Value()
+}
+
+// See doc for IterateReverse()
+func (r *reverseIterator) Reset() {
+	r.n = 0
+}
+
+// See doc for ListIterator.Section()
+func (r *reverseIterator) Section() (*Config, error) {
+	if r.Finished() {
+		return iteratorSection(r)
+	}
+	return r.sync().Section()
+}
@@ -0,0 +1,151 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChainedFilters(t *testing.T) {
+	c, err := NewConfigFromYaml([]byte(`
+plugins:
+  - {name: auth, enabled: true, kind: core}
+  - {name: cache, enabled: false, kind: core}
+  - {name: metrics, enabled: true, kind: extra}
+  - {name: tracing, enabled: true, kind: core}
+  - {name: debug, enabled: true}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := func(_ string, v *ConfigValue) bool {
+		section, err := v.MustConfig()
+		return err == nil && section.Get("enabled").Bool()
+	}
+	core := func(_ string, v *ConfigValue) bool {
+		section, err := v.MustConfig()
+		return err == nil && section.Get("kind").String() == "core"
+	}
+	name := func(v *ConfigValue) *ConfigValue {
+		section, _ := v.MustConfig()
+		return section.Get("name")
+	}
+	it := MapValues(Filter(Filter(c.Get("plugins").Iterate(), enabled), core), name)
+	for pass := 0; pass < 2; pass++ {
+		var names []string
+		var indices []int
+		for ; !it.Finished(); it.Next() {
+			names = append(names, it.Value().String())
+			indices = append(indices, it.Index())
+		}
+		if want := []string{"auth", "tracing"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("Pass %d: got %v, want %v", pass, names, want)
+		}
+		if want := []int{0, 1}; !reflect.DeepEqual(indices, want) {
+			t.Errorf("Pass %d: got indices %v, want %v", pass, indices, want)
+		}
+		if it.Value().IsSet() || it.Key() != "" {
+			t.Errorf("Pass %d: finished iterator is expected to give unset value and empty key", pass)
+		}
+		it.Next()
+		if !it.Finished() {
+			t.Errorf("Pass %d: Next() of finished iterator is expected to keep it finished", pass)
+		}
+		it.Reset()
+	}
+}
+
+func TestFilterOfMap(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"limits": map[string]interface{}{"a": 1, "b": 20, "c": 30, "d": 4}})
+	it := Filter(Filter(c.Get("limits").Iterate(), func(key string, _ *ConfigValue) bool { return key != "c" }),
+		func(_ string, v *ConfigValue) bool { return v.Int() > 2 })
+	var keys []string
+	for ; !it.Finished(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if want := []string{"b", "d"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Got %v, want %v", keys, want)
+	}
+	if it := Filter(c.Get("limits").Iterate(), func(string, *ConfigValue) bool { return false }); !it.Finished() {
+		t.Error("Iterator without matches is expected to be finished at once")
+	}
+}
+
+func TestIterateReverse(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"list": []interface{}{"a", "b", "c"},
+		"map":  map[string]interface{}{"x": 1, "y": 2},
+	})
+	tests := []struct {
+		key     string
+		indices []int
+		keys    []string
+		values  []interface{}
+	}{
+		{"list", []int{2, 1, 0}, []string{"", "", ""}, []interface{}{"c", "b", "a"}},
+		{"map", []int{1, 0}, []string{"y", "x"}, []interface{}{2, 1}},
+	}
+	for _, tt := range tests {
+		it := c.Get(tt.key).IterateReverse()
+		for pass := 0; pass < 2; pass++ {
+			var indices []int
+			var keys []string
+			var values []interface{}
+			for ; !it.Finished(); it.Next() {
+				indices = append(indices, it.Index())
+				keys = append(keys, it.Key())
+				values = append(values, it.Value().Raw())
+			}
+			if !reflect.DeepEqual(indices, tt.indices) || !reflect.DeepEqual(keys, tt.keys) || !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("'%s', pass %d: got indices %v, keys %v, values %v", tt.key, pass, indices, keys, values)
+			}
+			// finished iterator gives count of items as index, as iterator of Iterate() does
+			it.Next()
+			if !it.Finished() || it.Index() != len(tt.indices) {
+				t.Errorf("'%s', pass %d: finished iterator gives index %d, want %d", tt.key, pass, it.Index(), len(tt.indices))
+			}
+			if it.Value().IsSet() || it.Key() != "" {
+				t.Errorf("'%s', pass %d: finished iterator is expected to give unset value and empty key", tt.key, pass)
+			}
+			if _, err := it.Section(); err == nil {
+				t.Errorf("'%s', pass %d: Section() of finished iterator is expected to fail", tt.key, pass)
+			}
+			it.Reset()
+			if it.Finished() || it.Index() != tt.indices[0] {
+				t.Errorf("'%s', pass %d: Reset() is expected to point iterator to the last item, got index %d", tt.key, pass, it.Index())
+			}
+		}
+	}
+	if it := c.Get("list.0").IterateReverse(); !it.Finished() {
+		t.Errorf("Iterator of scalar is expected to be finished")
+	}
+}
+
+func TestFilterIndexAndReset(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"list": []interface{}{1, 2, 3, 4, 5, 6}})
+	even := func(_ string, v *ConfigValue) bool { return v.Int()%2 == 0 }
+	it := Filter(c.Get("list").IterateReverse(), even)
+	var values, indices []int
+	for ; !it.Finished(); it.Next() {
+		values = append(values, it.Value().Int())
+		indices = append(indices, it.Index())
+		if it.Index() == 1 {
+			// Reset() in the middle of iteration restarts it
+			it.Reset()
+			if it.Index() != 0 || it.Value().Int() != 6 {
+				t.Fatalf("Reset(): got index %d and value %d", it.Index(), it.Value().Int())
+			}
+			values, indices = nil, nil
+			for ; !it.Finished(); it.Next() {
+				values = append(values, it.Value().Int())
+				indices = append(indices, it.Index())
+			}
+			break
+		}
+	}
+	if !reflect.DeepEqual(values, []int{6, 4, 2}) || !reflect.DeepEqual(indices, []int{0, 1, 2}) {
+		t.Errorf("Got values %v, indices %v", values, indices)
+	}
+	if it.Index() != 3 {
+		t.Errorf("Finished filter is expected to give count of matched items as index, got %d", it.Index())
+	}
+}
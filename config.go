@@ -4,8 +4,14 @@
 package conf8n
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -14,13 +20,33 @@ const (
 
 // Base struct of the package. Represents loaded configuration.
 type Config struct {
-	data map[string]interface{}
+	data       map[string]interface{}
+	source     string
+	sep        string
+	foldCase   bool
+	prec       KeyPrecedence
+	sources    map[string]Source
+	order      map[string][]string
+	path       []string
+	deprecated *deprecations
+	accessed   *sync.Map
+	secrets    *secretStore
+	provenance *provenance
+	overrides  *overrideStack
+	index      *keyIndex
+	cache      *lookupCache
+	changes    *uint64
+	cow        *cowState
+	lazy       *lazySection
+	parent     *Config
 }
 
 // Represents value, got from config by given key or through iteration.
 // Has methods to cast underlying interface value to concrete type.
 type ConfigValue struct {
-	v interface{}
+	v   interface{}
+	c   *Config
+	key string
 }
 
 type Iterator interface {
@@ -29,11 +55,16 @@ type Iterator interface {
 	Index() int
 	Key() string
 	Value() *ConfigValue
+	Reset()
+	Section() (*Config, error)
 }
 
 type ListIterator struct {
-	a []interface{}
-	i int
+	a     []interface{}
+	i     int
+	c     *Config
+	key   string
+	keyed bool
 }
 
 type MapIterator struct {
@@ -47,32 +78,498 @@ type EmptyIterator struct{}
 // For most cases you can use more high-level constructors (see docs for NewConfigFromYaml(),
 // NewConfigFromJson() and NewConfigFromFile())
 func NewConfig(fromData map[string]interface{}) *Config {
-	return &Config{data: fromData}
+	return &Config{data: fromData, changes: new(uint64), overrides: &overrideStack{}}
+}
+
+// Sets separator of composite keys for config (SEP is used by default) and returns config itself.
+// Sub-configs inherit separator of their parent. Multi-character separators are supported;
+// empty separator causes panic
+func (c *Config) WithSeparator(sep string) *Config {
+	if sep == "" {
+		panic("conf8n: empty key separator")
+	}
+	c.sep = sep
+	c.invalidate()
+	return c
+}
+
+// Defines, which value is returned, when both top-level key, containing separator (like "db.user"),
+// and nested value (key "user" in section "db") are set
+type KeyPrecedence int
+
+const (
+	// Top-level key wins (default)
+	LiteralFirst KeyPrecedence = iota
+	// Nested value wins
+	NestedFirst
+	// Lookup fails: Get() returns empty value, GetE() and Must* methods report error, naming both candidates
+	ErrorOnAmbiguity
+)
+
+// Sets precedence of top-level keys, containing separator, over nested values (see KeyPrecedence)
+// and returns config itself. Sub-configs inherit precedence of their parent
+func (c *Config) WithPrecedence(p KeyPrecedence) *Config {
+	c.prec = p
+	c.invalidate()
+	return c
+}
+
+// Get value by top-level key as is, without splitting it to parts
+func (c *Config) GetLiteral(key string) *ConfigValue {
+	_, v, _, _ := nodeLookup(c.root(), key, c.foldCase)
+	return c.value(v)
+}
+
+// Switches config to case-insensitive keys mode and returns config itself. In this mode Get(), Has(), Set()
+// and other lookup methods match keys ignoring case on every level of composite key. If there is no exact match,
+// and several keys differ only by case, the first of them in sorted order wins. Iteration still returns keys
+// in their original case. Sub-configs inherit the mode of their parent
+func (c *Config) CaseInsensitive() *Config {
+	c.foldCase = true
+	c.invalidate()
+	return c
 }
 
 // Get value by given key.
 // Supports nested keys: for example, key "db.user" could be interpreted as is, if set;
-// if not - system will lookup for value with key "user" in section with key "db"
+// if not - system will lookup for value with key "user" in section with key "db".
+// Numeric key parts are used as indices for slices: "servers.0.host"; negative indices count from the end
+// of slice ("releases.-1.version" refers to the last element). Empty key refers to the whole config
+// (so Get("").Config() gives config, equivalent to original one); keys with empty parts (like "a..b" or ".a")
+// are not found, unless they are set as top-level keys. Separator inside of key part can be escaped
+// with backslash: "hosts.db\\.internal" refers to key "db.internal" in section "hosts" (see also GetPath())
 func (c *Config) Get(key string) *ConfigValue {
-	if v, ok := c.data[key]; ok {
-		return &ConfigValue{v: v}
+	v, _ := c.lookup(key)
+	return &ConfigValue{v: v, c: c, key: key}
+}
+
+// Same as Get(), but returns value itself instead of pointer, so it is not allocated on heap.
+// Useful for lookups in tight loops; all methods of ConfigValue work on values
+func (c *Config) GetV(key string) ConfigValue {
+	v, _ := c.lookup(key)
+	return ConfigValue{v: v, c: c, key: key}
+}
+
+// Returns all values, matching given pattern. Pattern is a composite key, where "*" part matches any key of section
+// or any index of slice, and "**" part matches any number of nested levels (e.g. "services.*.port" or "**.password").
+// Values are returned in deterministic order: sorted by keys for sections, by indices for slices.
+// Values, nested deeper than MaxDepth, are not matched by "**" (see GetAllE())
+func (c *Config) GetAll(pattern string) []*ConfigValue {
+	values, _ := c.GetAllE(pattern)
+	return values
+}
+
+// Same as GetAll(), but reports error (wrapping ErrMaxDepthExceeded), if "**" part meets values, nested deeper
+// than MaxDepth. Values, matched before that, are returned too
+func (c *Config) GetAllE(pattern string) ([]*ConfigValue, error) {
+	var values []*ConfigValue
+	err := matchPattern(c.tree(), splitKey(pattern, c.separator()), "", c.keyFormat(), func(path string, v interface{}) {
+		values = append(values, &ConfigValue{v: v, c: c, key: path})
+	})
+	return values, err
+}
+
+// Returns all values, found by given key, fanning out through slices: when slice is met during lookup,
+// the rest of the key is applied to each of its elements. For example, for config
+// "users: [{name: a}, {name: b}]" Pluck("users.name") returns values "a" and "b" (in slice order).
+// Elements, where the key can't be resolved, are skipped
+func (c *Config) Pluck(key string) []*ConfigValue {
+	var values []*ConfigValue
+	pluck(c.tree(), splitKey(key, c.separator()), "", c.keyFormat(), func(path string, v interface{}) {
+		values = append(values, &ConfigValue{v: v, c: c, key: path})
+	})
+	return values
+}
+
+// Returns true if key was provided in config (even if it was set to null, false, 0 or empty string).
+// Performs the same lookup as Get(), but without creating ConfigValue
+func (c *Config) Has(key string) bool {
+	_, found := c.lookup(key)
+	return found
+}
+
+// Sets value by given key (see Get() for key format). Missing intermediate sections are created.
+// Reports error if some of intermediate keys is set to non-map value
+func (c *Config) Set(key string, value interface{}) error {
+	c.detach()
+	if c.tree() == nil {
+		c.data = make(map[string]interface{})
+	}
+	if literal, _, ok := mapLookup(c.data, key, c.foldCase); ok {
+		c.data[literal] = value
+		c.invalidate()
+		c.forgetProvenance([]string{literal}, value)
+		return nil
+	}
+	chunks := splitKey(key, c.separator())
+	if err := checkKeyChunks(chunks); err != nil {
+		return c.keyError(key, err)
+	}
+	return c.setPath(chunks, value)
+}
+
+// Deletes value by given key (see Get() for key format). Returns false if key was not found.
+// Only keys of sections can be deleted (elements of slices can't)
+func (c *Config) Delete(key string) bool {
+	c.detach()
+	if literal, _, ok := mapLookup(c.tree(), key, c.foldCase); ok {
+		delete(c.data, literal)
+		c.invalidate()
+		return true
+	}
+	chunks := splitKey(key, c.separator())
+	if checkKeyChunks(chunks) != nil {
+		return false
+	}
+	if !deleteWithCompositeKey(c.tree(), chunks, c.keyFormat()) {
+		return false
+	}
+	c.invalidate()
+	return true
+}
+
+// Get value by path, given as list of key parts. Unlike Get(), key parts are never split,
+// so any keys (including containing separator) can be addressed
+func (c *Config) GetPath(path []string) *ConfigValue {
+	v, _ := getValueWithCompositeKey(c.tree(), path, c.keyFormat())
+	return &ConfigValue{v: v, c: c, key: joinKeyChunks(path, c.separator())}
+}
+
+// Same as Has(), but key is given as list of key parts (see GetPath())
+func (c *Config) HasPath(path []string) bool {
+	_, err := getValueWithCompositeKey(c.tree(), path, c.keyFormat())
+	return err == nil
+}
+
+// Same as Set(), but key is given as list of key parts (see GetPath())
+func (c *Config) SetPath(path []string, value interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("Empty path")
+	}
+	c.detach()
+	if c.tree() == nil {
+		c.data = make(map[string]interface{})
+	}
+	return c.setPath(path, value)
+}
+
+func (c *Config) setPath(chunks []string, value interface{}) error {
+	// intermediate sections may be created even on failure
+	defer c.invalidate()
+	if err := setValueWithCompositeKey(c.tree(), chunks, c.keyFormat(), value); err != nil {
+		return err
+	}
+	c.forgetProvenance(chunks, value)
+	return nil
+}
+
+// Same as Get(), but if value was not found, reports error, describing where and why the lookup stopped
+// (for example: "Key 'db.pool.size': 'db.pool' is a string (not a map), cannot descend to 'size'")
+func (c *Config) GetE(key string) (*ConfigValue, error) {
+	v, err := c.lookupE(key)
+	if err != nil {
+		return nil, c.keyError(key, err)
+	}
+	return &ConfigValue{v: v, c: c, key: key}, nil
+}
+
+// Returns value of the first key, that was found in config (see Get() for key format).
+// Key, explicitly set to null, is considered as found. Returns empty value if no one key was found
+func (c *Config) FirstSet(keys ...string) *ConfigValue {
+	v, _, _ := c.FirstSetKey(keys...)
+	return v
+}
+
+// Same as FirstSet(), but also returns the key, which value was taken from, and flag, reporting was it found at all
+func (c *Config) FirstSetKey(keys ...string) (*ConfigValue, string, bool) {
+	for _, key := range keys {
+		if v, found := c.lookup(key); found {
+			return c.value(v), key, true
+		}
+	}
+	return c.value(nil), "", false
+}
+
+// Get section by given key as new Config instance.
+// Unlike Get(key).Config(), reports error if key was not set or its value is not a map
+func (c *Config) Sub(key string) (*Config, error) {
+	v, found := c.lookup(key)
+	if !found {
+		return nil, wrapf(ErrNotSet, "Key '%s' is not set", key)
+	}
+	m := toStrMap(v)
+	if m == nil {
+		return nil, wrapf(ErrWrongType, "Key '%s' is a %s, not a section", key, kindOf(v))
+	}
+	return c.sub(m, c.keyChunks(key)), nil
+}
+
+// Same as Sub(), but panics on error. Useful for initialization code
+func (c *Config) MustSub(key string) *Config {
+	sub, err := c.Sub(key)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Returns error for unset value. If value was got by key, error describes why lookup failed
+func (v ConfigValue) notSetErr() error {
+	if v.c != nil && v.key != "" {
+		return v.c.keyError(v.key, v.notSetReason())
+	}
+	return ErrNotSet
+}
+
+// Describes, why value is not set (without the key)
+func (v ConfigValue) notSetReason() error {
+	if v.c != nil && v.key != "" {
+		if _, err := v.c.lookupE(v.key); err != nil {
+			return err
+		}
+		return wrapf(ErrNotSet, "value is null")
+	}
+	return ErrNotSet
+}
+
+// Returns full composite key of the value: key, it was got by, prefixed with path of sub-config from its root
+// config (see Config.PathFromRoot()), like "db.pool.size" for value, got by key "pool.size" from section "db".
+// Values of iterators have keys like "servers.3" or "db.host"; values, which origin is unknown (like ones
+// of GetLiteral() or detached values), have empty key
+func (v ConfigValue) Key() string {
+	if v.c == nil || v.key == "" || len(v.c.path) == 0 {
+		return v.key
+	}
+	return joinKeyChunks(append(append([]string{}, v.c.path...), v.chunks()...), v.c.separator())
+}
+
+// Returns parts of the key value was got by (nil if key is unknown)
+func (v ConfigValue) chunks() []string {
+	if v.c == nil || v.key == "" {
+		return nil
+	}
+	return v.c.keyChunks(v.key)
+}
+
+// Creates value, bound to config (c may be nil for detached values)
+func (c *Config) value(v interface{}) *ConfigValue {
+	return &ConfigValue{v: v, c: c}
+}
+
+// Creates sub-config with given data, inheriting settings of c (c may be nil).
+// Chunks are parts of sub-config's key in c; nil means the key is unknown
+func (c *Config) sub(data map[string]interface{}, chunks []string) *Config {
+	sub := NewConfig(data)
+	if c != nil {
+		sub.source = c.source
+		sub.sep = c.sep
+		sub.foldCase = c.foldCase
+		sub.prec = c.prec
+		sub.parent = c
+		sub.deprecated = c.deprecated
+		sub.accessed = c.accessed
+		sub.secrets = c.secrets
+		sub.changes = c.changes
+		if chunks != nil {
+			sub.sources = c.sources
+			sub.order = c.order
+			sub.provenance = c.provenance
+			sub.overrides = c.overrides
+			sub.index = c.index
+			sub.cow = c.cow
+			sub.path = append(append([]string{}, c.path...), chunks...)
+		}
+	}
+	return sub
+}
+
+// Splits key to parts, taking into account, that it could be found as top-level key
+func (c *Config) keyChunks(key string) []string {
+	if _, _, ok, _ := nodeLookup(c.root(), key, c.foldCase); ok {
+		return []string{key}
+	}
+	return splitKey(key, c.separator())
+}
+
+// Returns key separator of config
+func (c *Config) separator() string {
+	if c == nil || c.sep == "" {
+		return SEP
+	}
+	return c.sep
+}
+
+func (c *Config) keyFormat() keyFormat {
+	return keyFormat{sep: c.separator(), foldCase: c != nil && c.foldCase}
+}
+
+func (c *Config) lookup(key string) (interface{}, bool) {
+	v, err := c.lookupE(key)
+	return v, err == nil
+}
+
+func (c *Config) lookupE(key string) (interface{}, error) {
+	v, err := c.cachedLookup(key)
+	if c.overrides != nil && atomic.LoadUint32(&c.overrides.size) > 0 {
+		v, err = c.applyOverrides(key, v, err)
+	}
+	if c.deprecated != nil {
+		v, err = c.lookupDeprecated(key, v, err)
+	}
+	if c.accessed != nil && err == nil {
+		c.recordAccess(key, v)
+	}
+	return v, err
+}
+
+func (c *Config) lookupKey(key string) (interface{}, error) {
+	if key == "" {
+		return c.tree(), nil
+	}
+	literal, lv, lok, _ := nodeLookup(c.root(), key, c.foldCase)
+	if lok && c.prec == LiteralFirst {
+		return lv, nil
+	}
+	if !lok && c.index != nil {
+		if v, found := c.indexed(key); found {
+			return v, nil
+		}
+	}
+	if !lok && !strings.Contains(key, `\`) {
+		return getValueWithKey(c.root(), key, c.keyFormat())
+	}
+	chunks := splitKey(key, c.separator())
+	if err := checkKeyChunks(chunks); err != nil && !lok {
+		return nil, err
+	}
+	nv, err := getValueWithCompositeKey(c.root(), chunks, c.keyFormat())
+	if !lok || len(chunks) == 1 {
+		return nv, err
+	}
+	if err != nil {
+		return lv, nil
+	}
+	if c.prec == ErrorOnAmbiguity {
+		return nil, fmt.Errorf("ambiguous key: both top-level key '%s' and nested value '%s' are set",
+			literal, strings.Join(chunks, "' -> '"))
 	}
-	return &ConfigValue{v: getValueWithCompositeKey(c.data, strings.Split(key, SEP), 0)}
+	return nv, nil
+}
+
+// Returns config, this sub-config was got from (by ConfigValue.Config(), Sub() or similar methods).
+// Returns nil for root config
+func (c *Config) Parent() *Config {
+	return c.parent
+}
+
+// Returns the topmost config in chain of parents (config itself for root config)
+func (c *Config) Root() *Config {
+	root := c
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// Returns composite key of sub-config in root config ("" for root config or if the key is unknown)
+func (c *Config) PathFromRoot() string {
+	return joinKeyChunks(c.path, c.separator())
+}
+
+// Returns sorted list of top-level keys
+func (c *Config) Keys() []string {
+	return mapSortedKeys(c.tree())
+}
+
+// Returns iterator over top-level keys of config (in sorted order) and their values (see ConfigValue.Iterate())
+func (c *Config) Iterate() Iterator {
+	if len(c.tree()) == 0 {
+		return &EmptyIterator{}
+	}
+	return &MapIterator{&ListIterator{a: mapGetKeys(c.tree()), c: c, keyed: true}, c.tree()}
+}
+
+// Same as Iterate(), but iterates over shallow copy of top-level keys (see ConfigValue.IterateSnapshot())
+func (c *Config) IterateSnapshot() Iterator {
+	if len(c.tree()) == 0 {
+		return &EmptyIterator{}
+	}
+	data := copyStrMap(c.tree())
+	return &MapIterator{&ListIterator{a: mapGetKeys(data), c: c, keyed: true}, data}
+}
+
+// Calls fn for every top-level key of config in sorted order (see ConfigValue.Each())
+func (c *Config) Each(fn func(i int, key string, v *ConfigValue) error) error {
+	return eachItem(c.Iterate(), fn)
+}
+
+// Returns sorted list of composite keys (like "db.account.login") for every leaf value of config.
+// Slices are considered as leaves. Separators in key parts are escaped (see Get()).
+// Leaves, nested deeper than MaxDepth, are omitted (see AllKeysE())
+func (c *Config) AllKeys() []string {
+	keys, _ := c.allKeys(false)
+	return keys
+}
+
+// Same as AllKeys(), but reports error (wrapping ErrMaxDepthExceeded), if config is nested deeper than MaxDepth.
+// Keys, found before that, are returned too
+func (c *Config) AllKeysE() ([]string, error) {
+	return c.allKeys(false)
+}
+
+// Same as AllKeys(), but also descends into slices, using element indices as key parts (like "servers.0.host")
+func (c *Config) AllKeysIndexed() []string {
+	keys, _ := c.allKeys(true)
+	return keys
+}
+
+func (c *Config) allKeys(descendSlices bool) ([]string, error) {
+	keys := make([]string, 0, len(c.tree()))
+	if len(c.tree()) == 0 {
+		return keys, nil
+	}
+	err := walkLeaves(c.tree(), "", c.separator(), descendSlices, func(path string, _ interface{}) {
+		keys = append(keys, path)
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// Returns count of top-level keys
+func (c *Config) Len() int {
+	return len(c.tree())
+}
+
+// Returns true if config has no keys
+func (c *Config) IsEmpty() bool {
+	return len(c.tree()) == 0
+}
+
+// Returns count of scalar (not map or slice) values in config, including nested into sections and slices
+func (c *Config) LeafCount() int {
+	count := 0
+	walkTree(c.tree(), "", c.separator(), func(_ string, v interface{}) error {
+		if k := kindOf(v); k != Map && k != Slice {
+			count++
+		}
+		return nil
+	})
+	return count
 }
 
 // Returns true if key was set and we has not nil value
-func (v *ConfigValue) IsSet() bool {
+func (v ConfigValue) IsSet() bool {
 	return v.v != nil
 }
 
 // Returns true if value can be interpreted as slice
-func (v *ConfigValue) IsSlice() bool {
+func (v ConfigValue) IsSlice() bool {
 	_, is := v.v.([]interface{})
 	return is
 }
 
 // Returns true if value can be interpreted as map
-func (v *ConfigValue) IsMap() bool {
+func (v ConfigValue) IsMap() bool {
 	if _, is := v.v.(map[string]interface{}); is {
 		return true
 	}
@@ -80,86 +577,207 @@ func (v *ConfigValue) IsMap() bool {
 	return is
 }
 
+// Returns true if value is string
+func (v ConfigValue) IsString() bool {
+	_, is := v.v.(string)
+	return is
+}
+
+// Returns true if value is int (i.e. MustInt() will succeed).
+// Note that JSON decoder represents all numbers as float, so whole numbers from JSON are not ints
+func (v ConfigValue) IsInt() bool {
+	_, is := v.v.(int)
+	return is
+}
+
+// Returns true if value is float (i.e. MustFloat() will succeed)
+func (v ConfigValue) IsFloat() bool {
+	_, is := v.v.(float64)
+	return is
+}
+
+// Returns true if value is bool
+func (v ConfigValue) IsBool() bool {
+	_, is := v.v.(bool)
+	return is
+}
+
 // Silently converts value to int - even if key was not set in config
-func (v *ConfigValue) Int() int {
+func (v ConfigValue) Int() int {
 	i, _ := v.v.(int)
 	return i
 }
 
 // Silently converts value to string
-func (v *ConfigValue) String() string {
+func (v ConfigValue) String() string {
 	s, _ := v.v.(string)
 	return s
 }
 
 // Silently converts value to float
-func (v *ConfigValue) Float() float64 {
+func (v ConfigValue) Float() float64 {
 	f, _ := v.v.(float64)
 	return f
 }
 
 // Silently converts value to bool
-func (v *ConfigValue) Bool() bool {
+func (v ConfigValue) Bool() bool {
 	b, _ := v.v.(bool)
 	return b
 }
 
+// Silently converts value to time.Duration. Value must be a string in format, supported by time.ParseDuration()
+func (v ConfigValue) Duration() time.Duration {
+	d, _ := toDuration(v.v)
+	return d
+}
+
+// Silently converts value to slice of strings. Non-string elements are converted to empty strings;
+// nil is returned if value is not a slice. Single string value is not converted: see SplitString() for that
+func (v ConfigValue) StringSlice() []string {
+	a, ok := v.v.([]interface{})
+	if !ok {
+		return nil
+	}
+	strs := make([]string, len(a))
+	for i, el := range a {
+		strs[i], _ = el.(string)
+	}
+	return strs
+}
+
+// Same as StringSlice(), but if value is a single string, splits it by given separator (e.g. "a, b, c" -> ["a", "b", "c"]).
+// Whitespace around segments is trimmed; empty segments are dropped, if dropEmpty is true.
+// Useful for values, that can be overridden from environment, where lists can only be given as strings
+func (v ConfigValue) SplitString(sep string, dropEmpty bool) []string {
+	s, ok := v.v.(string)
+	if !ok {
+		return v.StringSlice()
+	}
+	parts := strings.Split(s, sep)
+	strs := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" && dropEmpty {
+			continue
+		}
+		strs = append(strs, p)
+	}
+	return strs
+}
+
 // Returns underlying value withou casting (as interface{})
-func (v *ConfigValue) Raw() interface{} {
+func (v ConfigValue) Raw() interface{} {
 	return v.v
 }
 
-// Get new Config instance from value
-func (v *ConfigValue) Config() *Config {
-	return NewConfig(toStrMap(v.v))
+// Get new Config instance from value. Section with keys of other types than strings is not converted
+// until it is needed (see lazySub()), so taking single value from big section is cheap
+func (v ConfigValue) Config() *Config {
+	if m, ok := v.v.(map[interface{}]interface{}); ok {
+		return v.c.lazySub(m, v.chunks())
+	}
+	return v.c.sub(toStrMap(v.v), v.chunks())
+}
+
+// Same as Config(), but reports error if value is not a map or some of its keys can't be converted to strings
+// (Config() silently skips such keys)
+func (v ConfigValue) MustConfig() (*Config, error) {
+	if !v.IsSet() {
+		return nil, v.notSetErr()
+	}
+	m, skipped := toStrMapE(v.v)
+	if m == nil {
+		return nil, v.typeError("map")
+	}
+	if len(skipped) > 0 {
+		return nil, wrapf(ErrWrongType, "Map has keys, that can't be converted to strings: %v", skipped)
+	}
+	return v.c.sub(m, v.chunks()), nil
+}
+
+// Get list of Config instances from value, that must be a slice of maps.
+// Reports error if value is not a slice or some of its elements is not a map
+func (v ConfigValue) ConfigSlice() ([]*Config, error) {
+	if !v.IsSet() {
+		return nil, v.notSetErr()
+	}
+	a, ok := v.v.([]interface{})
+	if !ok {
+		return nil, v.typeError("slice")
+	}
+	configs := make([]*Config, 0, len(a))
+	for i, el := range a {
+		m := toStrMap(el)
+		if m == nil {
+			return nil, wrapf(ErrWrongType, "Element %d is not map: %s", i, showValue(v.key, el))
+		}
+		var chunks []string
+		if key := v.chunks(); key != nil {
+			chunks = append(key, strconv.Itoa(i))
+		}
+		configs = append(configs, v.c.sub(m, chunks))
+	}
+	return configs, nil
 }
 
 // Tries to cast value to int; reports error if key was not set or it was non int
-func (v *ConfigValue) MustInt() (int, error) {
+func (v ConfigValue) MustInt() (int, error) {
 	if !v.IsSet() {
-		return 0, fmt.Errorf("Value is not set")
+		return 0, v.notSetErr()
 	}
 	if i, ok := v.v.(int); ok {
 		return i, nil
 	}
-	return 0, fmt.Errorf("Value is not int: %v", v.v)
+	return 0, v.typeError("int")
 }
 
 // Tries to cast value to string; reports error if key was not set or it was non string
-func (v *ConfigValue) MustString() (string, error) {
+func (v ConfigValue) MustString() (string, error) {
 	if !v.IsSet() {
-		return "", fmt.Errorf("Value is not set")
+		return "", v.notSetErr()
 	}
 	if s, ok := v.v.(string); ok {
 		return s, nil
 	}
-	return "", fmt.Errorf("Value is not string: %v", v.v)
+	return "", v.typeError("string")
 }
 
 // Tries to cast value to float; reports error if key was not set or it was non float
-func (v *ConfigValue) MustFloat() (float64, error) {
+func (v ConfigValue) MustFloat() (float64, error) {
 	if !v.IsSet() {
-		return .0, fmt.Errorf("Value is not set")
+		return .0, v.notSetErr()
 	}
 	if f, ok := v.v.(float64); ok {
 		return f, nil
 	}
-	return .0, fmt.Errorf("Value is not float: %v", v.v)
+	return .0, v.typeError("float")
 }
 
 // Tries to cast value to bool; reports error if key was not set or it was non bool
-func (v *ConfigValue) MustBool() (bool, error) {
+func (v ConfigValue) MustBool() (bool, error) {
 	if !v.IsSet() {
-		return false, fmt.Errorf("Value is not set")
+		return false, v.notSetErr()
 	}
 	if b, ok := v.v.(bool); ok {
 		return b, nil
 	}
-	return false, fmt.Errorf("Value is not bool: %v", v.v)
+	return false, v.typeError("bool")
+}
+
+// Tries to cast value to time.Duration; reports error if key was not set or it can't be parsed as duration
+func (v ConfigValue) MustDuration() (time.Duration, error) {
+	if !v.IsSet() {
+		return 0, v.notSetErr()
+	}
+	if d, ok := toDuration(v.v); ok {
+		return d, nil
+	}
+	return 0, v.typeError("duration")
 }
 
 // Tries to cast value to int. If it was not set, or can't be casted, returns given default value
-func (v *ConfigValue) DefInt(def int) int {
+func (v ConfigValue) DefInt(def int) int {
 	if i, ok := v.v.(int); ok {
 		return i
 	}
@@ -167,7 +785,7 @@ func (v *ConfigValue) DefInt(def int) int {
 }
 
 // Tries to cast value to string. If it was not set, or can't be casted, returns given default value
-func (v *ConfigValue) DefString(def string) string {
+func (v ConfigValue) DefString(def string) string {
 	if s, ok := v.v.(string); ok {
 		return s
 	}
@@ -175,7 +793,7 @@ func (v *ConfigValue) DefString(def string) string {
 }
 
 // Tries to cast value to float. If it was not set, or can't be casted, returns given default value
-func (v *ConfigValue) DefFloat(def float64) float64 {
+func (v ConfigValue) DefFloat(def float64) float64 {
 	if f, ok := v.v.(float64); ok {
 		return f
 	}
@@ -183,42 +801,182 @@ func (v *ConfigValue) DefFloat(def float64) float64 {
 }
 
 // Tries to cast value to bool. If it was not set, or can't be casted, returns given default value
-func (v *ConfigValue) DefBool(def bool) bool {
+func (v ConfigValue) DefBool(def bool) bool {
 	if b, ok := v.v.(bool); ok {
 		return b
 	}
 	return def
 }
 
+// Tries to cast value to time.Duration. If it was not set, or can't be casted, returns given default value
+func (v ConfigValue) DefDuration(def time.Duration) time.Duration {
+	if d, ok := toDuration(v.v); ok {
+		return d
+	}
+	return def
+}
+
+// Returns string value, if it matches one of allowed values; otherwise reports error, listing allowed values
+func (v ConfigValue) OneOf(allowed ...string) (string, error) {
+	return v.oneOf(false, allowed)
+}
+
+// Same as OneOf(), but matches values case-insensitively. Returns matched entry of allowed list (not the value itself)
+func (v ConfigValue) OneOfFold(allowed ...string) (string, error) {
+	return v.oneOf(true, allowed)
+}
+
+// Same as OneOf(), but returns given default value if value is not set.
+// Explicitly set value, that is not allowed, is still reported as error
+func (v ConfigValue) DefOneOf(def string, allowed ...string) (string, error) {
+	if !v.IsSet() {
+		return def, nil
+	}
+	return v.oneOf(false, allowed)
+}
+
+func (v ConfigValue) oneOf(fold bool, allowed []string) (string, error) {
+	s, err := v.MustString()
+	if err != nil {
+		return "", err
+	}
+	for _, a := range allowed {
+		if s == a || (fold && strings.EqualFold(s, a)) {
+			return a, nil
+		}
+	}
+	return "", wrapf(ErrWrongType, "Value %s is not one of: %s", showValue(v.key, s), strings.Join(allowed, ", "))
+}
+
 // Tries to cast value to slice and return count of its elements. Returns 0 on failure
-func (v *ConfigValue) Count() int {
+func (v ConfigValue) Count() int {
 	if a, ok := v.v.([]interface{}); ok {
 		return len(a)
 	}
 	return 0
 }
 
-// Returns iterator for the value (if it was set as array).
+// Returns sorted list of keys if value is a map; nil otherwise
+func (v ConfigValue) Keys() []string {
+	if m := toStrMap(v.v); m != nil {
+		return mapSortedKeys(m)
+	}
+	return nil
+}
+
+// Returns i-th element of slice value. Negative index counts from the end (-1 is the last element).
+// Returns empty value if value is not a slice or index is out of range
+func (v ConfigValue) At(i int) *ConfigValue {
+	el, _ := v.MustAt(i)
+	if el == nil {
+		return v.c.value(nil)
+	}
+	return el
+}
+
+// Same as At(), but reports error if value is not a slice or index is out of range
+func (v ConfigValue) MustAt(i int) (*ConfigValue, error) {
+	if !v.IsSet() {
+		return nil, v.notSetErr()
+	}
+	a, ok := v.v.([]interface{})
+	if !ok {
+		return nil, v.typeError("slice")
+	}
+	idx := i
+	if idx < 0 {
+		idx += len(a)
+	}
+	if idx < 0 || idx >= len(a) {
+		return nil, wrapf(ErrIndexOutOfRange, "Index %d out of range (len %d)", i, len(a))
+	}
+	el := v.c.value(a[idx])
+	if v.c != nil && v.key != "" {
+		el.key = joinKey(v.key, strconv.Itoa(idx), v.c.separator())
+	}
+	return el, nil
+}
+
+// Returns iterator for the value (if it was set as array or map). Map keys are sorted once, when iterator is created,
+// so iteration order is the same on every run (and is the same, as order of Keys(), AllKeys(), Walk(), etc).
 //
 // Example 1 (array key iteration):
 // 	for i := config.Get("myArrayValue").Iterate(); !i.Finished(); i.Next() {
 // 		fmt.Println(i.Value())
 // 	}
 //
-// Example 2 (map key iteration):
+// Example 2 (map key iteration, keys are iterated in sorted order):
 // 	for i := config.Get("myMapValue").Iterate(); !i.Finished(); i.Next() {
 // 		fmt.Println(i.Key(), ":", i.Value())
 // 	}
-func (v *ConfigValue) Iterate() Iterator {
+//
+// Access to finished iterator is safe: Value() returns unset value, Key() returns empty string
+// and Index() returns count of iterated items.
+// Iterator can be rewound with Reset() for another pass. Results are undefined, if config was changed between passes
+func (v ConfigValue) Iterate() Iterator {
+	if a, ok := v.v.([]interface{}); ok {
+		return &ListIterator{a: a, c: v.c, key: v.key, keyed: v.key != ""}
+	}
+	if m := toStrMap(v.v); m != nil {
+		return &MapIterator{&ListIterator{a: mapGetKeys(m), c: v.c, key: v.key, keyed: v.key != ""}, m}
+	}
+	return &EmptyIterator{}
+}
+
+// Same as Iterate(), but iterates over copy of slice or map, made when iterator is created, so later changes
+// of the value (like Set() of its keys) are not visible to iteration. Copy is shallow: nested sections and
+// slices are shared with config
+func (v ConfigValue) IterateSnapshot() Iterator {
 	if a, ok := v.v.([]interface{}); ok {
-		return &ListIterator{a, 0}
+		return (&ConfigValue{v: append([]interface{}{}, a...), c: v.c, key: v.key}).Iterate()
 	}
 	if m := toStrMap(v.v); m != nil {
-		return &MapIterator{&ListIterator{mapGetKeys(m), 0}, m}
+		return (&ConfigValue{v: copyStrMap(m), c: v.c, key: v.key}).Iterate()
 	}
 	return &EmptyIterator{}
 }
 
+func copyStrMap(m map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
+
+// Same as Iterate(), but reports error if value is not set or is neither slice nor map
+// (empty slices and maps give finished iterator without error)
+func (v ConfigValue) IterateE() (Iterator, error) {
+	if !v.IsSet() {
+		return nil, v.notSetErr()
+	}
+	if k := kindOf(v.v); k != Slice && k != Map {
+		return nil, valueError(v, wrapf(ErrNotIterable, "Value is a %s, not a slice or map: %s", k, showValue(v.key, v.v)))
+	}
+	return v.Iterate(), nil
+}
+
+// Can be returned by Each() callback to stop iteration without error
+var SkipRest = errors.New("skip rest")
+
+// Calls fn for every item of slice (key is empty) or map (in sorted keys order; i is index of the key)
+// and returns the first error, returned by fn. SkipRest error stops iteration, but is not returned.
+// Does nothing, if value is neither slice nor map
+func (v ConfigValue) Each(fn func(i int, key string, v *ConfigValue) error) error {
+	return eachItem(v.Iterate(), fn)
+}
+
+func eachItem(it Iterator, fn func(i int, key string, v *ConfigValue) error) error {
+	for ; !it.Finished(); it.Next() {
+		if err := fn(it.Index(), it.Key(), it.Value()); err == SkipRest {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // See doc for ConfigValue.Iterate()
 func (i *ListIterator) Next() {
 	if !i.Finished() {
@@ -233,7 +991,30 @@ func (i *ListIterator) Finished() bool {
 
 // See doc for ConfigValue.Iterate()
 func (i *ListIterator) Value() *ConfigValue {
-	return &ConfigValue{i.a[i.i]}
+	v := i.ValueV()
+	return &v
+}
+
+// Same as Value(), but returns value itself, so it is not allocated on heap (unless its key has to be built)
+func (i *ListIterator) ValueV() ConfigValue {
+	if i.Finished() {
+		return ConfigValue{c: i.c}
+	}
+	if !i.keyed {
+		// index is not formatted, if it is not needed for key
+		return ConfigValue{v: i.a[i.i], c: i.c}
+	}
+	return i.child(strconv.Itoa(i.i), i.a[i.i])
+}
+
+// Returns current item as Config; reports error (containing index of the item), if it is not a map
+func (i *ListIterator) Section() (*Config, error) {
+	return iteratorSection(i)
+}
+
+// Returns iterator to the first item (map keys are not collected again)
+func (i *ListIterator) Reset() {
+	i.i = 0
 }
 
 // Returns current iteration index
@@ -248,12 +1029,39 @@ func (i *ListIterator) Key() string {
 
 // See doc for ConfigValue.Iterate()
 func (i *MapIterator) Value() *ConfigValue {
-	return &ConfigValue{i.m[i.Key()]}
+	v := i.ValueV()
+	return &v
+}
+
+// Same as Value(), but returns value itself, so it is not allocated on heap (unless its key has to be built)
+func (i *MapIterator) ValueV() ConfigValue {
+	if i.Finished() {
+		return ConfigValue{c: i.c}
+	}
+	return i.child(i.Key(), i.m[i.Key()])
 }
 
 // Return current key
 func (i *MapIterator) Key() string {
-	return i.ListIterator.Value().String()
+	if i.Finished() {
+		return ""
+	}
+	s, _ := i.a[i.i].(string)
+	return s
+}
+
+// Returns current item as Config; reports error (containing key of the item), if it is not a map
+func (i *MapIterator) Section() (*Config, error) {
+	return iteratorSection(i)
+}
+
+// Creates value of iterated item; its key is known, if key of iterated value is known
+func (i *ListIterator) child(segment string, v interface{}) ConfigValue {
+	value := ConfigValue{v: v, c: i.c}
+	if i.keyed {
+		value.key = joinKey(i.key, segment, i.c.separator())
+	}
+	return value
 }
 
 // No action here
@@ -263,7 +1071,7 @@ func (i *EmptyIterator) Next() {
 
 // Always return empty value
 func (i *EmptyIterator) Value() *ConfigValue {
-	return &ConfigValue{nil}
+	return &ConfigValue{v: nil}
 }
 
 // Always return true
@@ -271,6 +1079,33 @@ func (i *EmptyIterator) Finished() bool {
 	return true
 }
 
+// No action here
+func (i *EmptyIterator) Reset() {
+	//pass
+}
+
+// Always return error
+func (i *EmptyIterator) Section() (*Config, error) {
+	return iteratorSection(i)
+}
+
+func iteratorSection(it Iterator) (*Config, error) {
+	if it.Finished() {
+		return nil, wrapf(ErrNotSet, "No current item: iterator is finished")
+	}
+	v := it.Value()
+	c, err := v.MustConfig()
+	switch {
+	case err == nil:
+		return c, nil
+	case v.key != "":
+		return nil, v.c.keyError(v.key, err)
+	case it.Key() != "":
+		return nil, fmt.Errorf("Key '%s': %w", it.Key(), err)
+	}
+	return nil, fmt.Errorf("Item %d: %w", it.Index(), err)
+}
+
 // Always return 0
 func (i *EmptyIterator) Index() int {
 	return 0
@@ -281,10 +1116,72 @@ func (i *EmptyIterator) Key() string {
 	return ""
 }
 
+// Returns sorted keys of map as iterator items
 func mapGetKeys(m map[string]interface{}) []interface{} {
-	a := make([]interface{}, 0, len(m))
-	for k, _ := range m {
-		a = append(a, k)
+	keys := mapSortedKeys(m)
+	a := make([]interface{}, len(keys))
+	for i, k := range keys {
+		a[i] = k
 	}
 	return a
 }
+
+// Returns keys of map, sorted numerically, if all of them are numbers (like stringified keys of YAML map {1: a, 10: b, 2: c}),
+// or lexically otherwise. Only integers and plain decimals (like "-1.5") are numbers here, not "NaN", "Inf" or "1e3"
+func mapSortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	numeric := true
+	for k := range m {
+		keys = append(keys, k)
+		numeric = numeric && isDecimal(k)
+	}
+	if !numeric {
+		sort.Strings(keys)
+		return keys
+	}
+	nums := make([]float64, len(keys))
+	for i, k := range keys {
+		nums[i], _ = strconv.ParseFloat(k, 64)
+	}
+	sort.Sort(numericKeys{keys, nums})
+	return keys
+}
+
+// Keys with their numeric values, sorted by values (equal values are ordered lexically)
+type numericKeys struct {
+	keys []string
+	nums []float64
+}
+
+func (k numericKeys) Len() int { return len(k.keys) }
+
+func (k numericKeys) Less(i, j int) bool {
+	if k.nums[i] == k.nums[j] {
+		return k.keys[i] < k.keys[j]
+	}
+	return k.nums[i] < k.nums[j]
+}
+
+func (k numericKeys) Swap(i, j int) {
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.nums[i], k.nums[j] = k.nums[j], k.nums[i]
+}
+
+// Checks if string is integer or decimal fraction: optional minus, digits, optional dot with digits
+func isDecimal(s string) bool {
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	digits, dot := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] >= '0' && s[i] <= '9':
+			digits++
+		case s[i] == '.' && !dot && digits > 0:
+			dot, digits = true, 0
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
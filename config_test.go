@@ -0,0 +1,285 @@
+package conf8n
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNonStringKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		keys []string
+		get  string
+		want interface{}
+	}{
+		{"int", "m: {10: a, 2: b, 1: c, -3: d}", []string{"-3", "1", "2", "10"}, "m.10", "a"},
+		{"float", "m: {1.5: a, 0.25: b, 10: c, -0.5: d}", []string{"-0.5", "0.25", "1.5", "10"}, "m.10", "c"},
+		{"bool", "m: {true: a, false: b}", []string{"false", "true"}, "m.true", "a"},
+		{"nan and inf", "m: {.nan: a, .inf: b, 1: c, 20: d}", []string{"+Inf", "1", "20", "NaN"}, "m.1", "c"},
+		{"exponent", `m: {"1e3": a, 2: b}`, []string{"1e3", "2"}, "m.2", "b"},
+		{"mixed", "m: {10: a, 9: b, x: c}", []string{"10", "9", "x"}, "m.x", "c"},
+	}
+	for _, tt := range tests {
+		c, err := NewConfigFromYaml([]byte(tt.yaml))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if keys := c.Get("m").Keys(); !reflect.DeepEqual(keys, tt.keys) {
+			t.Errorf("%s: got keys %q, want %q", tt.name, keys, tt.keys)
+		}
+		if v := c.Get(tt.get).Raw(); v != tt.want {
+			t.Errorf("%s: Get(%q): got %v, want %v", tt.name, tt.get, v, tt.want)
+		}
+	}
+}
+
+func TestIsDecimal(t *testing.T) {
+	for s, want := range map[string]bool{
+		"0": true, "42": true, "-7": true, "1.5": true, "-0.25": true,
+		"": false, "-": false, ".5": false, "1.": false, "1.2.3": false, "+1": false,
+		"1e3": false, "NaN": false, "Inf": false, "-Inf": false, "0x10": false, "1_000": false,
+	} {
+		if got := isDecimal(s); got != want {
+			t.Errorf("isDecimal(%q): got %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestScalarPredicates(t *testing.T) {
+	yamlConf, err := NewConfigFromYaml([]byte("s: abc\nq: '10'\ni: 10\nf: 1.5\nw: 2.0\nb: true\ny: yes\nn: null\nl: [1]\nm: {a: 1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonConf, err := NewConfigFromJson([]byte(`{"s": "abc", "q": "10", "i": 10, "f": 1.5, "w": 2.0, "b": true, "y": "yes",
+		"n": null, "l": [1], "m": {"a": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flags: IsString, IsInt, IsFloat, IsBool
+	tests := []struct {
+		key        string
+		yaml, json [4]bool
+	}{
+		{"s", [4]bool{true, false, false, false}, [4]bool{true, false, false, false}},
+		{"q", [4]bool{true, false, false, false}, [4]bool{true, false, false, false}},
+		// JSON decoder gives floats for all numbers, so whole numbers from JSON are not ints
+		{"i", [4]bool{false, true, false, false}, [4]bool{false, false, true, false}},
+		{"f", [4]bool{false, false, true, false}, [4]bool{false, false, true, false}},
+		{"w", [4]bool{false, false, true, false}, [4]bool{false, false, true, false}},
+		{"b", [4]bool{false, false, false, true}, [4]bool{false, false, false, true}},
+		{"y", [4]bool{false, false, false, true}, [4]bool{true, false, false, false}},
+		{"n", [4]bool{}, [4]bool{}},
+		{"l", [4]bool{}, [4]bool{}},
+		{"m", [4]bool{}, [4]bool{}},
+		{"missing", [4]bool{}, [4]bool{}},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			name string
+			conf *Config
+			want [4]bool
+		}{{"yaml", yamlConf, tt.yaml}, {"json", jsonConf, tt.json}} {
+			v := c.conf.Get(tt.key)
+			if got := [4]bool{v.IsString(), v.IsInt(), v.IsFloat(), v.IsBool()}; got != c.want {
+				t.Errorf("%s, key '%s': got %v, want %v", c.name, tt.key, got, c.want)
+			}
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"replicas": []interface{}{"a", "b", "c"},
+		"empty":    []interface{}{},
+		"name":     "x",
+	})
+	tests := []struct {
+		key   string
+		index int
+		want  interface{}
+		path  string
+		err   error
+	}{
+		{"replicas", 0, "a", "replicas.0", nil},
+		{"replicas", 2, "c", "replicas.2", nil},
+		{"replicas", -1, "c", "replicas.2", nil},
+		{"replicas", -3, "a", "replicas.0", nil},
+		{"replicas", 3, nil, "", ErrIndexOutOfRange},
+		{"replicas", -4, nil, "", ErrIndexOutOfRange},
+		{"empty", 0, nil, "", ErrIndexOutOfRange},
+		{"empty", -1, nil, "", ErrIndexOutOfRange},
+		{"name", 0, nil, "", ErrWrongType},
+		{"missing", 0, nil, "", ErrNotSet},
+	}
+	for _, tt := range tests {
+		el, err := c.Get(tt.key).MustAt(tt.index)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) || el != nil {
+				t.Errorf("MustAt(%d) of '%s': got %v, %v; want error %v", tt.index, tt.key, el, err, tt.err)
+			}
+		} else if err != nil || el.Raw() != tt.want || el.key != tt.path {
+			t.Errorf("MustAt(%d) of '%s': got %v, %v", tt.index, tt.key, el, err)
+		}
+		if v := c.Get(tt.key).At(tt.index); v == nil || v.Raw() != tt.want || v.IsSet() != (tt.err == nil) {
+			t.Errorf("At(%d) of '%s': got %v, want %v", tt.index, tt.key, v, tt.want)
+		}
+	}
+}
+
+// Negative indices in key paths must address the same elements, as iteration gives, and must not be confused
+// with keys of maps, that start with minus sign
+func TestNegativeIndicesWithIteration(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"releases": []interface{}{
+			map[string]interface{}{"version": "1.0", "tags": []interface{}{"old"}},
+			map[string]interface{}{"version": "1.1", "tags": []interface{}{}},
+			map[string]interface{}{"version": "2.0", "tags": []interface{}{"latest", "stable"}},
+		},
+		"offsets": map[string]interface{}{"-1": "minus one", "1": "one"},
+	})
+	releases := c.Get("releases")
+	n := releases.Count()
+	for it := releases.Iterate(); !it.Finished(); it.Next() {
+		i := it.Index()
+		neg := strconv.Itoa(i - n)
+		version := it.Value().Config().Get("version").String()
+		if got := c.Get("releases." + neg + ".version").String(); got != version {
+			t.Errorf("releases.%s.version: got %q, want %q", neg, got, version)
+		}
+		if got := releases.At(i - n).Key(); got != it.Value().Key() {
+			t.Errorf("At(%d): got key %q, want %q", i-n, got, it.Value().Key())
+		}
+		// iteration over element, taken by negative index
+		tags := c.Get("releases." + neg + ".tags")
+		var fromIteration []interface{}
+		for tagIt := tags.Iterate(); !tagIt.Finished(); tagIt.Next() {
+			fromIteration = append(fromIteration, tagIt.Value().Raw())
+			if got := c.Get(fmt.Sprintf("releases.%s.tags.%d", neg, tagIt.Index()-tags.Count())).Raw(); got != tagIt.Value().Raw() {
+				t.Errorf("Tag %d of release %s: got %v, want %v", tagIt.Index(), neg, got, tagIt.Value().Raw())
+			}
+		}
+		if want := it.Value().Config().Get("tags").Count(); len(fromIteration) != want {
+			t.Errorf("Tags of release %s: got %v, want %d items", neg, fromIteration, want)
+		}
+	}
+	for _, key := range []string{"releases.-4.version", "releases.3.version", "releases.-1.tags.-3"} {
+		if c.Has(key) {
+			t.Errorf("Key '%s' is out of range, but it is set", key)
+		}
+	}
+	// keys of maps are not indices
+	var keys []string
+	for it := c.Get("offsets").Iterate(); !it.Finished(); it.Next() {
+		keys = append(keys, it.Key())
+		if got := c.Get("offsets." + it.Key()).Raw(); got != it.Value().Raw() {
+			t.Errorf("offsets.%s: got %v, want %v", it.Key(), got, it.Value().Raw())
+		}
+	}
+	if want := []string{"-1", "1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Got keys %v, want %v", keys, want)
+	}
+}
+
+func TestFinishedIterators(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"list":       []interface{}{1, 2},
+		"map":        map[string]interface{}{"a": 1, "b": 2},
+		"empty_list": []interface{}{},
+		"empty_map":  map[string]interface{}{},
+		"scalar":     1,
+	})
+	tests := []struct {
+		key   string
+		count int
+	}{
+		{"list", 2},
+		{"map", 2},
+		{"empty_list", 0},
+		{"empty_map", 0},
+		{"scalar", 0},
+		{"missing", 0},
+	}
+	for _, tt := range tests {
+		it := c.Get(tt.key).Iterate()
+		for ; !it.Finished(); it.Next() {
+		}
+		// access to exhausted iterator must not panic
+		it.Next()
+		if !it.Finished() || it.Index() != tt.count {
+			t.Errorf("'%s': got finished %v and index %d, want index %d", tt.key, it.Finished(), it.Index(), tt.count)
+		}
+		if v := it.Value(); v == nil || v.IsSet() {
+			t.Errorf("'%s': finished iterator is expected to give unset value, got %v", tt.key, v)
+		}
+		if k := it.Key(); k != "" {
+			t.Errorf("'%s': finished iterator is expected to give empty key, got %q", tt.key, k)
+		}
+		if _, err := it.Section(); err == nil {
+			t.Errorf("'%s': Section() of finished iterator is expected to fail", tt.key)
+		}
+	}
+}
+
+func TestGetVDoesNotAllocate(t *testing.T) {
+	c := NewConfig(nestedTree(3, 4))
+	key := nestedKey(3)
+	if allocs := testing.AllocsPerRun(100, func() { c.GetV(key).Int() }); allocs != 0 {
+		t.Errorf("GetV(): %v allocations", allocs)
+	}
+	list := make([]interface{}, 1000)
+	it := (&ConfigValue{v: list, c: c}).Iterate().(*ListIterator)
+	allocs := testing.AllocsPerRun(10, func() {
+		for it.Reset(); !it.Finished(); it.Next() {
+			it.ValueV()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("ValueV() of iterator without keys: %v allocations", allocs)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	c := NewConfig(nestedTree(3, 4))
+	key := nestedKey(3)
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Get(key).Int()
+		}
+	})
+	b.Run("GetV", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.GetV(key).Int()
+		}
+	})
+}
+
+func BenchmarkIterateValues(b *testing.B) {
+	list := make([]interface{}, 1000)
+	for i := range list {
+		list[i] = i
+	}
+	c := NewConfig(map[string]interface{}{"list": list})
+	b.Run("Value", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for it := c.GetV("list").Iterate(); !it.Finished(); it.Next() {
+				it.Value().Int()
+			}
+		}
+	})
+	b.Run("ValueV", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			it := (&ConfigValue{v: list, c: c}).Iterate().(*ListIterator)
+			for ; !it.Finished(); it.Next() {
+				it.ValueV().Int()
+			}
+		}
+	})
+}
@@ -6,7 +6,7 @@ import (
 	"gopkg.in/yaml.v2"
 	"io"
 	"io/ioutil"
-	"os"
+	"log"
 	"path/filepath"
 	"strings"
 )
@@ -16,49 +16,214 @@ const (
 	YAML = "yaml"
 )
 
-// Creates Config instance from YAML-encoded data
-func NewConfigFromYaml(data []byte) (*Config, error) {
-	m := make(map[string]interface{})
-	if err := yaml.Unmarshal([]byte(data), &m); err != nil {
+// Option of config loading, accepted by NewConfigFromXXX() constructors
+type LoadOption func(o *loadOptions)
+
+type loadOptions struct {
+	schemas   []*Schema
+	checks    []func(c *Config) error
+	warn      func(problem ValidationError)
+	expandEnv bool
+	strictEnv bool
+	intern    bool
+}
+
+// Makes constructor substitute environment variables in raw data before parsing it, so they can be used anywhere
+// in the document: in keys, numeric and boolean values, etc. Syntax is the same, as for Config.ExpandEnv(); "$$"
+// produces literal "$". If strict is true, references to unset variables without defaults make loading fail
+// with error, listing all of them; otherwise they are replaced by empty strings. Failed ${VAR:?message}
+// references make loading fail regardless of strict.
+// Note that source locations (see Config.SourceOf()) refer to expanded data
+func WithExpandEnv(strict bool) LoadOption {
+	return func(o *loadOptions) {
+		o.expandEnv = true
+		o.strictEnv = strict
+	}
+}
+
+// Makes constructor apply defaults of schema to loaded config and validate it (see Schema.ApplyDefaults() and
+// Schema.Validate()). If config is invalid, constructor returns nil config and ValidationErrors (parse errors are
+// returned as ParseError, so they can be told apart with errors.As()); warnings don't make loading fail (see OnWarning()).
+// Can be given several times to check several schemas
+func WithSchema(schema *Schema) LoadOption {
+	return func(o *loadOptions) {
+		o.schemas = append(o.schemas, schema)
+	}
+}
+
+// Makes constructor check loaded config with given function (after checks of WithSchema() options); if it returns
+// error, constructor returns nil config and the error. As watchers reload configs with the same options (see
+// WatchFile() and WatchURL()), invalid configs are not applied on reloading too. Can be given several times
+func WithValidation(fn func(c *Config) error) LoadOption {
+	return func(o *loadOptions) {
+		o.checks = append(o.checks, fn)
+	}
+}
+
+// Makes constructor deduplicate strings of loaded config (see Config.Intern()). Saves memory for big generated
+// configs with many similar entries at cost of extra pass over data at loading
+func WithInterning() LoadOption {
+	return func(o *loadOptions) {
+		o.intern = true
+	}
+}
+
+// Sets callback for warnings, found by schemas of WithSchema() option (see SchemaKey.Warn()). Warnings don't abort
+// loading; by default they are written to standard logger
+func OnWarning(fn func(problem ValidationError)) LoadOption {
+	return func(o *loadOptions) {
+		o.warn = fn
+	}
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	o := &loadOptions{warn: func(problem ValidationError) {
+		log.Printf("conf8n: %v", problem)
+	}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Prepares raw data for parsing
+func (o *loadOptions) preprocess(data []byte) ([]byte, error) {
+	if !o.expandEnv {
+		return data, nil
+	}
+	expanded, missing, err := expandVars(string(data), EnvVars())
+	if err != nil {
 		return nil, err
 	}
-	return NewConfig(m), nil
+	if o.strictEnv && len(missing) > 0 {
+		return nil, fmt.Errorf("Environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return []byte(expanded), nil
 }
 
-// Creates Config instance from JSON-encoded data
-func NewConfigFromJson(data []byte) (*Config, error) {
-	m := make(map[string]interface{})
-	if err := json.Unmarshal(data, &m); err != nil {
+// Applies options to just loaded config
+func (o *loadOptions) apply(c *Config) (*Config, error) {
+	var problems ValidationErrors
+	for _, schema := range o.schemas {
+		if _, err := schema.ApplyDefaults(c); err != nil {
+			return nil, err
+		}
+		problems = append(problems, schema.Check(c)...)
+	}
+	problems.result()
+	for _, warning := range problems.Warnings() {
+		o.warn(warning)
+	}
+	if err := problems.Errors().result(); err != nil {
 		return nil, err
 	}
-	return NewConfig(m), nil
+	for _, check := range o.checks {
+		if err := check(c); err != nil {
+			return nil, err
+		}
+	}
+	if o.intern {
+		c.Intern()
+	}
+	return c, nil
+}
+
+// Creates Config instance from YAML-encoded data.
+// Config remembers locations of the keys in data (see Config.SourceOf()) and their order (see ConfigValue.IterateOrdered())
+func NewConfigFromYaml(data []byte, opts ...LoadOption) (*Config, error) {
+	return newConfigFromData(data, YAML, "", opts)
+}
+
+// Creates Config instance from JSON-encoded data.
+// Config remembers locations of the keys in data (see Config.SourceOf()) and their order (see ConfigValue.IterateOrdered())
+func NewConfigFromJson(data []byte, opts ...LoadOption) (*Config, error) {
+	return newConfigFromData(data, JSON, "", opts)
 }
 
 // Creates Config instance from data in file.
 // Data encoding will be defined from file extension (".json" & ".yaml" supported for the moment)
-func NewConfigFromFile(filename string) (*Config, error) {
-	var f io.Reader
-	var err error
-	if f, err = os.Open(filename); err != nil {
+// Created config remembers the file path, so relative paths in it can be resolved (see ConfigValue.Path())
+func NewConfigFromFile(filename string, opts ...LoadOption) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
 		return nil, err
 	}
+	o := newLoadOptions(opts)
 	ext := strings.TrimLeft(strings.ToLower(filepath.Ext(filename)), ".")
-	return NewConfigFromReader(f, ext)
+	c, err := parse(data, ext, filename, o)
+	if err != nil {
+		return nil, err
+	}
+	c.source = filename
+	c.setSourceFile(filename)
+	return o.apply(c)
 }
 
 // Creates Config instance with data from io.Reader. Specifying of incoming data format is required
-func NewConfigFromReader(r io.Reader, format string) (*Config, error) {
+func NewConfigFromReader(r io.Reader, format string, opts ...LoadOption) (*Config, error) {
 	var data []byte
 	var err error
 	if data, err = ioutil.ReadAll(r); err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return newConfigFromData(data, format, "", opts)
+}
+
+// Creates Config instance from data of given format; source describes origin of data for error messages
+func newConfigFromData(data []byte, format, source string, opts []LoadOption) (*Config, error) {
+	o := newLoadOptions(opts)
+	c, err := parse(data, format, source, o)
+	if err != nil {
 		return nil, err
 	}
-	switch format {
-	case JSON:
-		return NewConfigFromJson(data)
-	case YAML:
-		return NewConfigFromYaml(data)
-	default:
+	return o.apply(c)
+}
+
+// Parses (preprocessed) data of given format. Options, that apply to parsed config, are not applied here.
+// Errors of preprocessing and parsing are returned as ParseError
+func parse(data []byte, format, source string, o *loadOptions) (*Config, error) {
+	if format != JSON && format != YAML {
 		return nil, fmt.Errorf("Unknown config format: '%s'", format)
 	}
+	data, err := o.preprocess(data)
+	if err != nil {
+		return nil, &ParseError{Source: source, Err: err}
+	}
+	var c *Config
+	if format == JSON {
+		c, err = parseJson(data)
+	} else {
+		c, err = parseYaml(data)
+	}
+	if err != nil {
+		return nil, newParseError(source, data, err)
+	}
+	return c, nil
+}
+
+// Values are decoded by yaml.v2 (so "yes" is bool and dates are strings, as they always were); locations and order
+// of keys are taken from node tree of yaml.v3 (see yamlLayout())
+func parseYaml(data []byte) (*Config, error) {
+	m := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	// yaml decoder gives maps with non-string keys (like 80: ...) as map[interface{}]interface{}
+	if _, err := normalizeTree(m); err != nil {
+		return nil, err
+	}
+	c := NewConfig(m)
+	c.sources, c.order = yamlLayout(data)
+	return c, nil
+}
+
+func parseJson(data []byte) (*Config, error) {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	c := NewConfig(m)
+	c.sources = jsonPositions(data)
+	c.order = jsonKeyOrder(data)
+	return c, nil
 }
@@ -0,0 +1,84 @@
+package conf8n
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestNewConfigFromYamlResolving(t *testing.T) {
+	c, err := NewConfigFromYaml([]byte("debug: yes\nt: 2020-01-01\nports:\n  80: http\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.MustBool("debug"); err != nil || !v {
+		t.Errorf("debug: got %v, %v; want true", v, err)
+	}
+	if v, err := c.MustString("t"); err != nil || v != "2020-01-01" {
+		t.Errorf("t: got %q, %v; want 2020-01-01", v, err)
+	}
+	if v := c.Get("ports.80").String(); v != "http" {
+		t.Errorf("ports.80: got %q; want http", v)
+	}
+	if src, ok := c.SourceOf("ports.80"); !ok || src.Line != 4 || src.Column != 3 {
+		t.Errorf("SourceOf(ports.80): got %v, %v; want line 4, column 3", src, ok)
+	}
+}
+
+// Returns YAML document with section "routes" of given count of entries
+func routesYaml(count int) []byte {
+	var b strings.Builder
+	b.WriteString("routes:\n")
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&b, "  r%d: {host: backend-%d.local, timeout: %d, retries: 3}\n", i, i%10, i)
+	}
+	return []byte(b.String())
+}
+
+func TestYamlSectionsAreNormalized(t *testing.T) {
+	c, err := NewConfigFromYaml(append(routesYaml(3), "list: [{a: 1}, [{b: 2}]]\n"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walkTree(c.tree(), "", SEP, func(path string, v interface{}) error {
+		if _, isRaw := v.(map[interface{}]interface{}); isRaw {
+			t.Errorf("Section '%s' is not converted to string-keyed map", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Reads 100 keys from big YAML section: normalized at load (as NewConfigFromYaml() does) and not normalized
+// (as yaml.v2 decodes it), so sections are converted on lookups
+func BenchmarkGetFromYamlSection(b *testing.B) {
+	data := routesYaml(5000)
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("routes.r%d.timeout", i*50)
+	}
+	normalized, err := NewConfigFromYaml(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		b.Fatal(err)
+	}
+	for name, c := range map[string]*Config{"normalized": normalized, "raw": NewConfig(raw)} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, key := range keys {
+					if _, found := c.lookup(key); !found {
+						b.Fatalf("Key %s is not set", key)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package conf8n
+
+import "sync"
+
+// Data of config, created by Overlay(), shared with its base. Shared by the config and its sub-configs
+type cowState struct {
+	mu       sync.Mutex
+	root     *Config
+	detached bool
+}
+
+// Returns config with other config deep-merged over c (as Merge() does), sharing data with c: only sections, changed
+// by other, are copied, other sections of c are referenced by the result. So overlaying of small configs (like
+// per-tenant settings) over big base config is cheap, but it requires base config (c) to stay unchanged while
+// the result is used. On the first change of the result (by Set(), Delete(), Merge(), ExpandEnv() and other methods,
+// called on it or its sub-configs) its data is fully copied, so changes never leak into the base.
+// Sub-configs of the result, taken before the change, don't see it. Sections, nested deeper than MaxDepth,
+// are shared with c and other even after the change
+func (c *Config) Overlay(other *Config) *Config {
+	var data map[string]interface{}
+	if other == nil {
+		merged, _ := overlayTrees(c.tree(), map[string]interface{}{}, nil)
+		data = merged.(map[string]interface{})
+	} else {
+		merged, _ := overlayTrees(c.tree(), other.tree(), nil)
+		data = merged.(map[string]interface{})
+	}
+	res := c.sub(data, nil)
+	res.cow = &cowState{root: res}
+	return res
+}
+
+// Returns src merged over dst (see mergeTrees()), sharing unchanged subtrees of dst. Key parts of dst are used
+// for error message (see deepCopyDepth())
+func overlayTrees(dst, src interface{}, chunks []string) (interface{}, error) {
+	md, ms := toStrMap(dst), toStrMap(src)
+	if md == nil || ms == nil {
+		return deepCopyDepth(src, chunks)
+	}
+	if len(md) > 0 && len(ms) > 0 && len(chunks) >= MaxDepth {
+		return src, maxDepthErr(joinKeyChunks(chunks, SEP))
+	}
+	var err, childErr error
+	merged := make(map[string]interface{}, len(md)+len(ms))
+	for k, v := range md {
+		merged[k] = v
+	}
+	for k, v := range ms {
+		if merged[k], childErr = overlayTrees(md[k], v, append(chunks, k)); err == nil {
+			err = childErr
+		}
+	}
+	return merged, err
+}
+
+// Makes data of config, created by Overlay() (or of its sub-config), its own before it is changed
+func (c *Config) detach() {
+	s := c.cow
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.detached {
+		// sections, nested deeper than MaxDepth, stay shared
+		data, _ := deepCopy(s.root.tree())
+		s.root.data = data.(map[string]interface{})
+		s.root.invalidate()
+		s.detached = true
+	}
+	if c != s.root {
+		v, _ := getValueWithCompositeKey(s.root.tree(), c.path, c.keyFormat())
+		if m, isMap := v.(map[string]interface{}); isMap {
+			c.data, c.lazy = m, nil
+			c.invalidate()
+		}
+	}
+	c.cow = nil
+}
@@ -0,0 +1,67 @@
+package conf8n
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOverlayChangesDontLeakIntoBase(t *testing.T) {
+	base := NewConfig(nestedTree(3, 3))
+	before, _ := deepCopy(base.tree())
+	tenant := NewConfig(map[string]interface{}{"k0": map[string]interface{}{"k1": map[string]interface{}{"k2": "tenant"}}})
+	changes := map[string]func(c *Config) error{
+		"Set":       func(c *Config) error { return c.Set("k1.k1.k1", "changed") },
+		"Set new":   func(c *Config) error { return c.Set("k2.new", 1) },
+		"Delete":    func(c *Config) error { c.Delete("k2.k0"); return nil },
+		"Merge":     func(c *Config) error { c.Merge(NewConfig(map[string]interface{}{"k0": 1}), ""); return nil },
+		"ExpandEnv": func(c *Config) error { return c.ExpandEnv(false) },
+		"Set of sub-config": func(c *Config) error {
+			sub, err := c.Sub("k1.k2")
+			if err != nil {
+				return err
+			}
+			return sub.Set("k0", "changed")
+		},
+	}
+	for name, change := range changes {
+		res := base.Overlay(tenant)
+		if got := res.Get("k0.k1.k2").Raw(); got != "tenant" {
+			t.Errorf("%s: overlaid value: got %v", name, got)
+		}
+		if err := change(res); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(base.tree(), before) {
+			t.Errorf("%s: change of overlay result leaked into base", name)
+		}
+	}
+}
+
+// Builds 1000 tenant configs over big base: sharing data with Overlay() and copying it with Merge()
+func BenchmarkTenantOverlays(b *testing.B) {
+	base := NewConfig(nestedTree(5, 6))
+	tenants := make([]*Config, 1000)
+	for i := range tenants {
+		tenants[i] = NewConfig(map[string]interface{}{
+			"k1": map[string]interface{}{"k2": map[string]interface{}{"k3": fmt.Sprint("tenant-", i)}},
+		})
+	}
+	b.Run("Overlay", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, tenant := range tenants {
+				base.Overlay(tenant)
+			}
+		}
+	})
+	b.Run("Merge", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, tenant := range tenants {
+				data, _ := deepCopy(base.tree())
+				NewConfig(data.(map[string]interface{})).Merge(tenant, "")
+			}
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package conf8n
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Decodes whole config into struct, pointed by dest (see ConfigValue.Decode())
+func (c *Config) Decode(dest interface{}) error {
+	return (&ConfigValue{v: c.tree(), c: c}).Decode(dest)
+}
+
+// Same as Scan(), but also supports structs (and pointers). Struct fields are filled from map keys, given with tag
+// `conf8n:"name"` (or matching field name case-insensitively; tag "-" skips the field). Embedded structs without
+// tag are filled from the same map. After population fields are checked against constraints, given in tags:
+//
+//	Port int    `conf8n:"port,required" validate:"min=1,max=65535"`
+//	Mode string `validate:"oneof=dev prod"`
+//	Name string `validate:"required,regexp=^[a-z]+$"`
+//
+// Supported constraints: required (key must be set), min and max (value of numbers; length of strings, slices
+// and maps), oneof (space-separated list of allowed values), regexp (must be the last one, as it may contain commas)
+// and names of validators, registered with RegisterValidator(). Unknown constraints and invalid limits or patterns
+// are reported for the field, even if its key is not set.
+// Decoding doesn't stop on the first problem: all of them are reported at once with paths of the keys
+func (v ConfigValue) Decode(dest interface{}) error {
+	return v.decode(dest, &decoder{})
+}
+
+// Same as Decode(), but also reports every key of config, that doesn't match any struct field. Keys, starting with
+// one of given prefixes (like "x-"), are ignored; prefixes are matched against both key part and the whole key
+func (v ConfigValue) DecodeStrict(dest interface{}, ignorePrefixes ...string) error {
+	return v.decode(dest, &decoder{strict: true, ignore: ignorePrefixes})
+}
+
+// Decodes whole config into struct, reporting unknown keys (see ConfigValue.DecodeStrict())
+func (c *Config) DecodeStrict(dest interface{}, ignorePrefixes ...string) error {
+	return (&ConfigValue{v: c.tree(), c: c}).DecodeStrict(dest, ignorePrefixes...)
+}
+
+func (v ConfigValue) decode(dest interface{}, d *decoder) error {
+	if dest == nil {
+		return wrapf(ErrNilDest, "Decode destination is nil")
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr {
+		return wrapf(ErrNotPointer, "Decode destination is not a pointer: %T", dest)
+	}
+	if rv.IsNil() {
+		return wrapf(ErrNilDest, "Decode destination is nil")
+	}
+	if !v.IsSet() {
+		return v.notSetErr()
+	}
+	d.c = v.c
+	d.sep = v.c.separator()
+	d.decode(v.v, rv.Elem(), v.key)
+	return d.problems.result()
+}
+
+type decoder struct {
+	c        *Config
+	sep      string
+	strict   bool
+	ignore   []string
+	problems ValidationErrors
+}
+
+func (d *decoder) fail(path, keyword string, err error) {
+	d.problems = append(d.problems, d.c.validationError(path, keyword, err))
+}
+
+func (d *decoder) decode(src interface{}, dst reflect.Value, path string) {
+	switch {
+	case dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType), dst.Type() == timeType:
+	case dst.Kind() == reflect.Ptr:
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return
+		}
+		elem := reflect.New(dst.Type().Elem())
+		d.decode(src, elem.Elem(), path)
+		dst.Set(elem)
+		return
+	case dst.Kind() == reflect.Struct:
+		consumed := make(map[string]bool)
+		d.decodeStruct(src, dst, path, consumed)
+		if m := toStrMap(src); m != nil && d.strict {
+			for _, k := range mapSortedKeys(m) {
+				if !consumed[k] && !d.ignored(k, joinKey(path, k, d.sep)) {
+					d.fail(joinKey(path, k, d.sep), "unknown", fmt.Errorf("Key doesn't match any field of %s", dst.Type()))
+				}
+			}
+		}
+		return
+	case dst.Kind() == reflect.Slice:
+		if a, ok := src.([]interface{}); ok {
+			slice := reflect.MakeSlice(dst.Type(), len(a), len(a))
+			for i, el := range a {
+				d.decode(el, slice.Index(i), joinKey(path, strconv.Itoa(i), d.sep))
+			}
+			dst.Set(slice)
+			return
+		}
+	case dst.Kind() == reflect.Map && dst.Type().Key().Kind() == reflect.String:
+		if m := toStrMap(src); m != nil {
+			res := reflect.MakeMapWithSize(dst.Type(), len(m))
+			for _, k := range mapSortedKeys(m) {
+				elem := reflect.New(dst.Type().Elem()).Elem()
+				d.decode(m[k], elem, joinKey(path, k, d.sep))
+				res.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+			}
+			dst.Set(res)
+			return
+		}
+	}
+	if err := scanInto(src, dst, path, true); err != nil {
+		d.fail(path, "", err)
+	}
+}
+
+// Checks, that key should not be reported as unknown
+func (d *decoder) ignored(key, path string) bool {
+	for _, prefix := range d.ignore {
+		if strings.HasPrefix(key, prefix) || strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fills fields of struct from map, marking used keys as consumed
+func (d *decoder) decodeStruct(src interface{}, dst reflect.Value, path string, consumed map[string]bool) {
+	m := toStrMap(src)
+	if m == nil {
+		d.fail(path, "", incompatibleErr(src, dst, path))
+		return
+	}
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("conf8n")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, opts = tag[:comma], tag[comma+1:]
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.decodeStruct(src, dst.Field(i), path, consumed)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		key, value, found := mapLookup(m, name, true)
+		consumed[key] = true
+		fieldPath := joinKey(path, key, d.sep)
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err != nil {
+			d.fail(fieldPath, "validate", err)
+			continue
+		}
+		if strings.Contains(","+opts+",", ",required,") {
+			rules = append([]rule{{name: "required"}}, rules...)
+		}
+		if !found || value == nil {
+			for _, r := range rules {
+				if r.name == "required" {
+					d.fail(fieldPath, "required", fmt.Errorf("Value is required, but not set"))
+					break
+				}
+			}
+			continue
+		}
+		before := len(d.problems)
+		d.decode(value, dst.Field(i), fieldPath)
+		if len(d.problems) > before {
+			continue
+		}
+		for _, r := range rules {
+			if err := r.check(dst.Field(i), &ConfigValue{v: value, c: d.c, key: fieldPath}); err != nil {
+				d.fail(fieldPath, r.name, err)
+			}
+		}
+	}
+}
+
+// Single constraint from "validate" tag
+type rule struct {
+	name, arg string
+	limit     float64        // for min and max
+	allowed   []string       // for oneof
+	re        *regexp.Regexp // for regexp
+}
+
+// Parsed "validate" tags (tag -> []rule), so patterns are compiled once
+var parsedRules sync.Map
+
+// Parses "validate" tag. Returns error for unknown constraints (neither built-in, nor registered validators),
+// invalid limits and patterns, so mistakes in tags are reported even if keys are not set
+func parseRules(tag string) ([]rule, error) {
+	if cached, found := parsedRules.Load(tag); found {
+		return cached.([]rule), nil
+	}
+	var rules []rule
+	for rest := tag; rest != ""; {
+		part := rest
+		if strings.HasPrefix(rest, "regexp=") {
+			rest = ""
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			part, rest = rest[:comma], rest[comma+1:]
+		} else {
+			rest = ""
+		}
+		name, arg := part, ""
+		if eq := strings.Index(part, "="); eq >= 0 {
+			name, arg = part[:eq], part[eq+1:]
+		}
+		r := rule{name: strings.TrimSpace(name), arg: arg}
+		switch r.name {
+		case "required":
+		case "min", "max":
+			limit, err := strconv.ParseFloat(r.arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid limit '%s' of constraint '%s'", r.arg, r.name)
+			}
+			r.limit = limit
+		case "oneof":
+			r.allowed = strings.Fields(r.arg)
+		case "regexp":
+			re, err := regexp.Compile(r.arg)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid pattern '%s': %v", r.arg, err)
+			}
+			r.re = re
+		default:
+			if _, found := lookupValidator(r.name); !found {
+				return nil, fmt.Errorf("Unknown constraint '%s'", r.name)
+			}
+		}
+		rules = append(rules, r)
+	}
+	parsedRules.Store(tag, rules)
+	return rules, nil
+}
+
+// Checks decoded value against the rule (raw is value of the key; it is passed to custom validators)
+func (r rule) check(v reflect.Value, raw *ConfigValue) error {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch r.name {
+	case "required":
+		return nil
+	case "min", "max":
+		n, what := measure(v)
+		if math.IsNaN(n) {
+			return fmt.Errorf("Can't check %s of %s value", r.name, v.Type())
+		}
+		shown := fmt.Sprint(n)
+		if what == "Value" {
+			shown = showValue(raw.key, v.Interface())
+		}
+		if r.name == "min" && n < r.limit {
+			return fmt.Errorf("%s %s is less than %v", what, shown, r.limit)
+		}
+		if r.name == "max" && n > r.limit {
+			return fmt.Errorf("%s %s is greater than %v", what, shown, r.limit)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range r.allowed {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("Value %s is not one of: %s", showValue(raw.key, s), strings.Join(r.allowed, ", "))
+	case "regexp":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("Can't match %s value with pattern", v.Type())
+		}
+		if !r.re.MatchString(v.String()) {
+			return fmt.Errorf("Value %s doesn't match pattern '%s'", showValue(raw.key, v.String()), r.arg)
+		}
+	default:
+		fn, found := lookupValidator(r.name)
+		if !found {
+			return fmt.Errorf("Unknown constraint '%s'", r.name)
+		}
+		return fn(raw)
+	}
+	return nil
+}
+
+// Returns value of number or length of string, slice or map (NaN for other kinds) and its description
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "Value"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "Value"
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "Value"
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "Length"
+	case reflect.Slice, reflect.Map:
+		return float64(v.Len()), "Length"
+	}
+	return math.NaN(), ""
+}
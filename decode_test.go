@@ -0,0 +1,119 @@
+package conf8n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeInvalidConstraints(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"port": 80})
+	tests := []struct {
+		name string
+		dest interface{}
+		want string
+	}{
+		{"unknown, key not set", &struct {
+			Host string `conf8n:"host" validate:"hostnme"`
+		}{}, "Key 'host': validate: Unknown constraint 'hostnme'"},
+		{"unknown, key set", &struct {
+			Port int `conf8n:"port" validate:"min=1,positiv"`
+		}{}, "Key 'port': validate: Unknown constraint 'positiv'"},
+		{"invalid limit", &struct {
+			Port int `conf8n:"port" validate:"max=x"`
+		}{}, "Key 'port': validate: Invalid limit 'x' of constraint 'max'"},
+		{"invalid pattern", &struct {
+			Name string `conf8n:"name" validate:"regexp=^[a-z"`
+		}{}, "Key 'name': validate: Invalid pattern '^[a-z'"},
+	}
+	for _, tt := range tests {
+		err := c.Decode(tt.dest)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeConstraints(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"port": 80, "mode": "dev", "name": "a,b"})
+	var dest struct {
+		Port int    `conf8n:"port" validate:"min=1,max=65535"`
+		Mode string `conf8n:"mode" validate:"oneof=dev prod"`
+		Name string `conf8n:"name" validate:"required,regexp=^[a-z]+,[a-z]+$"`
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.Decode(&dest); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	first, _ := parseRules("required,regexp=^[a-z]+,[a-z]+$")
+	second, _ := parseRules("required,regexp=^[a-z]+,[a-z]+$")
+	if len(first) != 2 || first[1].re == nil || first[1].re != second[1].re {
+		t.Errorf("Pattern is expected to be compiled once, got rules %v and %v", first, second)
+	}
+	c = NewConfig(map[string]interface{}{"port": 0, "mode": "test", "name": "ab"})
+	err := c.Decode(&dest)
+	for _, want := range []string{"Value 0 is less than 1", `Value "test" is not one of: dev, prod`, `Value "ab" doesn't match pattern`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error, got %v", want, err)
+		}
+	}
+}
+
+func TestDecodeConstraintsOfPointers(t *testing.T) {
+	type dest struct {
+		Port *int      `conf8n:"port" validate:"min=1,max=65535"`
+		Mode *string   `conf8n:"mode" validate:"oneof=dev prod"`
+		Name **string  `conf8n:"name" validate:"regexp=^[a-z]+$"`
+		Tags *[]string `conf8n:"tags" validate:"max=2"`
+	}
+	tests := []struct {
+		data map[string]interface{}
+		errs []string
+	}{
+		{map[string]interface{}{"port": 80, "mode": "dev", "name": "app", "tags": []interface{}{"a"}}, nil},
+		// unset pointers are not checked
+		{map[string]interface{}{}, nil},
+		{map[string]interface{}{"port": nil, "mode": nil}, nil},
+		{
+			map[string]interface{}{"port": 0, "mode": "test", "name": "App", "tags": []interface{}{"a", "b", "c"}},
+			[]string{"Value 0 is less than 1", `Value "test" is not one of: dev, prod`, `Value "App" doesn't match pattern`, "Length 3 is greater than 2"},
+		},
+	}
+	for i, tt := range tests {
+		var d dest
+		err := NewConfig(tt.data).Decode(&d)
+		if len(tt.errs) == 0 && err != nil {
+			t.Errorf("Case %d: unexpected error %v", i, err)
+		}
+		for _, want := range tt.errs {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Case %d: expected %q in error, got %v", i, want, err)
+			}
+		}
+	}
+}
+
+// JSON decoder gives all numbers as floats, so Decode() (unlike Scan()) stores whole floats into integer fields
+func TestDecodeWholeFloats(t *testing.T) {
+	c, err := NewConfigFromJson([]byte(`{"port": 8080, "ratio": 2.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dest struct {
+		Port int `conf8n:"port"`
+	}
+	if err := c.Decode(&dest); err != nil || dest.Port != 8080 {
+		t.Errorf("Got %d, %v", dest.Port, err)
+	}
+	var port int
+	if err := c.Get("port").Scan(&port); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Scan() of float into int: got error %v, want ErrWrongType", err)
+	}
+	var fractional struct {
+		Ratio int `conf8n:"ratio"`
+	}
+	if err := c.DecodeStrict(&fractional, "port"); err == nil {
+		t.Errorf("Float with fractional part is not expected to be decoded into int")
+	}
+}
@@ -0,0 +1,107 @@
+package conf8n
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// Registry of deprecated keys, shared by config and its sub-configs
+type deprecations struct {
+	mu    sync.Mutex
+	root  *Config
+	rules map[string]deprecation
+	used  map[string]bool
+	warn  func(oldKey, newKey, message string)
+}
+
+type deprecation struct {
+	newKey  string
+	message string
+}
+
+// Keys, which deprecation warnings were already reported in this process
+var deprecationWarned sync.Map
+
+// Registers deprecated key. Reading oldKey (also through sub-configs or as a part of composite key, like "oldKey.x")
+// still works, but if oldKey is not set, value of newKey is returned instead. On the first use of oldKey
+// warning is reported (once per process) through the callback, set by OnDeprecated(), or standard logger.
+// Keys are given relative to the config Deprecate() is called on; registry is shared with sub-configs
+func (c *Config) Deprecate(oldKey, newKey, message string) {
+	d := c.deprecationRegistry()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules[c.fullKey(oldKey)] = deprecation{newKey: c.fullKey(newKey), message: message}
+}
+
+// Sets callback for deprecated keys usage warnings (by default they are written to standard logger)
+func (c *Config) OnDeprecated(fn func(oldKey, newKey, message string)) {
+	d := c.deprecationRegistry()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.warn = fn
+}
+
+// Returns sorted list of deprecated keys, that were read from config
+func (c *Config) DeprecatedKeysUsed() []string {
+	if c.deprecated == nil {
+		return nil
+	}
+	c.deprecated.mu.Lock()
+	defer c.deprecated.mu.Unlock()
+	keys := make([]string, 0, len(c.deprecated.used))
+	for k := range c.deprecated.used {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Config) deprecationRegistry() *deprecations {
+	if c.deprecated == nil {
+		c.deprecated = &deprecations{
+			root:  c,
+			rules: make(map[string]deprecation),
+			used:  make(map[string]bool),
+		}
+	}
+	return c.deprecated
+}
+
+// Returns key, relative to the root config (with SEP as separator)
+func (c *Config) fullKey(key string) string {
+	return joinKeyChunks(append(append([]string{}, c.path...), c.keyChunks(key)...), SEP)
+}
+
+// Checks, if key (or some of its prefixes) is deprecated. If so, reports its usage and,
+// if value was not found by key, looks it up by new key
+func (c *Config) lookupDeprecated(key string, v interface{}, err error) (interface{}, error) {
+	chunks := append(append([]string{}, c.path...), c.keyChunks(key)...)
+	d := c.deprecated
+	for i := len(chunks); i > 0; i-- {
+		oldKey := joinKeyChunks(chunks[:i], SEP)
+		d.mu.Lock()
+		rule, found := d.rules[oldKey]
+		if found {
+			d.used[oldKey] = true
+		}
+		warn := d.warn
+		d.mu.Unlock()
+		if !found {
+			continue
+		}
+		if _, warned := deprecationWarned.LoadOrStore(oldKey+"\x00"+rule.newKey, true); !warned {
+			if warn != nil {
+				warn(oldKey, rule.newKey, rule.message)
+			} else {
+				log.Printf("conf8n: key '%s' is deprecated, use '%s' instead %s", oldKey, rule.newKey, rule.message)
+			}
+		}
+		if err == nil {
+			return v, nil
+		}
+		newChunks := append(splitKey(rule.newKey, SEP), chunks[i:]...)
+		return getValueWithCompositeKey(d.root.tree(), newChunks, keyFormat{sep: SEP, foldCase: c.foldCase})
+	}
+	return v, err
+}
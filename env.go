@@ -0,0 +1,164 @@
+package conf8n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Rules of mapping environment variables to config keys. Name of variable (without Prefix) is split to key parts
+// by NestingSep, and every part is converted by KeyTransform. With default options APP_DB__MAX_CONNS
+// (for Prefix "APP_") is mapped to key "db.max_conns", so keys, containing underscores, are not ambiguous
+type EnvOptions struct {
+	// Prefix of variables names (like "APP_"); variables without it are ignored
+	Prefix string
+	// Separator of key parts in variables names ("__" by default)
+	NestingSep string
+	// Separator of words in key parts of config ("_" by default); words of variables names are always separated by "_"
+	WordSep string
+	// Converts part of variable name to key part (by default it is lowercased, and "_" is replaced by WordSep)
+	KeyTransform func(part string) string
+	// Reverse of KeyTransform, used by ToEnv() (by default key part is uppercased, and WordSep is replaced by "_")
+	EnvTransform func(part string) string
+}
+
+func (o EnvOptions) withDefaults() EnvOptions {
+	if o.NestingSep == "" {
+		o.NestingSep = "__"
+	}
+	if o.WordSep == "" {
+		o.WordSep = "_"
+	}
+	if o.KeyTransform == nil {
+		o.KeyTransform = func(part string) string {
+			return strings.ReplaceAll(strings.ToLower(part), "_", o.WordSep)
+		}
+	}
+	if o.EnvTransform == nil {
+		o.EnvTransform = func(part string) string {
+			return strings.ReplaceAll(strings.ToUpper(part), o.WordSep, "_")
+		}
+	}
+	return o
+}
+
+// Returns key (as list of key parts, see Config.GetPath()) for variable name. Returns false if name doesn't have
+// the prefix or some of key parts is empty
+func (o EnvOptions) KeyPath(name string) ([]string, bool) {
+	o = o.withDefaults()
+	if !strings.HasPrefix(name, o.Prefix) || len(name) == len(o.Prefix) {
+		return nil, false
+	}
+	path := strings.Split(name[len(o.Prefix):], o.NestingSep)
+	for i, part := range path {
+		if path[i] = o.KeyTransform(part); part == "" || path[i] == "" {
+			return nil, false
+		}
+	}
+	return path, true
+}
+
+// Returns variable name for key, given as list of key parts (reverse of KeyPath())
+func (o EnvOptions) EnvName(path []string) string {
+	o = o.withDefaults()
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = o.EnvTransform(part)
+	}
+	return o.Prefix + strings.Join(parts, o.NestingSep)
+}
+
+// Returns sorted names of environment variables with given prefix
+func envNames(prefix string) []string {
+	var names []string
+	for _, kv := range os.Environ() {
+		if name := strings.SplitN(kv, "=", 2)[0]; strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Creates Config instance from environment variables, mapped to keys by given options (see EnvOptions).
+// Values are kept as strings. Reports error if some variable refers to a section, that is set by other variable
+// to a scalar value (like APP_DB and APP_DB__HOST)
+func NewConfigFromEnv(o EnvOptions, opts ...LoadOption) (*Config, error) {
+	c := NewConfig(make(map[string]interface{}))
+	for _, name := range envNames(o.Prefix) {
+		path, ok := o.KeyPath(name)
+		if !ok {
+			continue
+		}
+		if err := c.SetPath(path, os.Getenv(name)); err != nil {
+			return nil, fmt.Errorf("Environment variable %s: %w", name, err)
+		}
+	}
+	return newLoadOptions(opts).apply(c)
+}
+
+// Overrides values of config with environment variables, mapped to keys by given options (see EnvOptions).
+// Type of the value, set in config, is preserved the same way as for command line flags (see FlagValue());
+// keys, missing in config, are set to strings
+func (c *Config) OverrideFromEnv(o EnvOptions) error {
+	for _, name := range envNames(o.Prefix) {
+		path, ok := o.KeyPath(name)
+		if !ok {
+			continue
+		}
+		current, _ := getValueWithCompositeKey(c.tree(), path, c.keyFormat())
+		value, err := parseLike(current, os.Getenv(name))
+		if err == nil {
+			err = c.SetPath(path, value)
+		}
+		if err != nil {
+			return fmt.Errorf("Environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Returns leaf values of config as sorted list of "NAME=value" strings, which are mapped back to the same keys
+// by NewConfigFromEnv() and OverrideFromEnv(). Slices are given as comma-separated lists.
+// Leaves, nested deeper than MaxDepth, are omitted (see ToEnvE())
+func (c *Config) ToEnv(o EnvOptions) []string {
+	env, _ := c.ToEnvE(o)
+	return env
+}
+
+// Same as ToEnv(), but reports error (wrapping ErrMaxDepthExceeded), if config is nested deeper than MaxDepth.
+// Variables, found before that, are returned too
+func (c *Config) ToEnvE(o EnvOptions) ([]string, error) {
+	var env []string
+	err := walkLeaves(c.tree(), "", c.separator(), false, func(path string, v interface{}) {
+		env = append(env, o.EnvName(splitKey(path, c.separator()))+"="+formatPlain(v))
+	})
+	sort.Strings(env)
+	return env, err
+}
+
+// Checks, that every leaf key of config can be set through environment: its variable name must be mapped
+// back to the same key, and must not be shared with other keys. Returns all found problems
+func (c *Config) VerifyEnvMapping(o EnvOptions) error {
+	var problems ValidationErrors
+	owners := make(map[string]string)
+	err := walkLeaves(c.tree(), "", c.separator(), false, func(path string, _ interface{}) {
+		name := o.EnvName(splitKey(path, c.separator()))
+		back, ok := o.KeyPath(name)
+		switch {
+		case !ok || joinKeyChunks(back, c.separator()) != path:
+			problems = append(problems, c.validationError(path, "env",
+				fmt.Errorf("Variable %s doesn't map back to the key", name)))
+		case owners[name] != "":
+			problems = append(problems, c.validationError(path, "env",
+				fmt.Errorf("Variable %s is also mapped to key '%s'", name, owners[name])))
+		default:
+			owners[name] = path
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return problems.result()
+}
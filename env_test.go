@@ -0,0 +1,138 @@
+package conf8n
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvOptionsKeyPath(t *testing.T) {
+	dashed := EnvOptions{Prefix: "APP_", WordSep: "-"}
+	tests := []struct {
+		o    EnvOptions
+		name string
+		path []string
+	}{
+		{EnvOptions{Prefix: "APP_"}, "APP_DB__MAX_CONNS", []string{"db", "max_conns"}},
+		{EnvOptions{Prefix: "APP_"}, "APP_DB_MAX_CONNS", []string{"db_max_conns"}},
+		{EnvOptions{Prefix: "APP_"}, "APP_DB", []string{"db"}},
+		{EnvOptions{Prefix: "APP_"}, "APP_", nil},
+		{EnvOptions{Prefix: "APP_"}, "APP_DB__", nil},
+		{EnvOptions{Prefix: "APP_"}, "APP___DB", nil},
+		{EnvOptions{Prefix: "APP_"}, "OTHER_DB", nil},
+		{dashed, "APP_DB__MAX_CONNS", []string{"db", "max-conns"}},
+		{EnvOptions{Prefix: "APP_", NestingSep: "_"}, "APP_DB_MAX_CONNS", []string{"db", "max", "conns"}},
+		{EnvOptions{KeyTransform: strings.ToUpper}, "DB__HOST", []string{"DB", "HOST"}},
+	}
+	for _, test := range tests {
+		path, ok := test.o.KeyPath(test.name)
+		if ok != (test.path != nil) || !reflect.DeepEqual(path, test.path) {
+			t.Errorf("%s: got %v (%v), want %v", test.name, path, ok, test.path)
+		}
+		if ok {
+			if name := test.o.EnvName(path); name != test.name {
+				t.Errorf("%s: reverse mapping gives %s", test.name, name)
+			}
+		}
+	}
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	tree := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":      "db.local",
+			"max_conns": 10,
+		},
+		"feature_flags": map[string]interface{}{"new_ui": true},
+		"ratio":         0.5,
+		"servers":       []interface{}{"a", "b"},
+	}
+	o := EnvOptions{Prefix: "CONF8N_TEST_"}
+	c := NewConfig(tree)
+	env := c.ToEnv(o)
+	want := []string{
+		"CONF8N_TEST_DB__HOST=db.local",
+		"CONF8N_TEST_DB__MAX_CONNS=10",
+		"CONF8N_TEST_FEATURE_FLAGS__NEW_UI=true",
+		"CONF8N_TEST_RATIO=0.5",
+		"CONF8N_TEST_SERVERS=a,b",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("ToEnv(): got %v, want %v", env, want)
+	}
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		os.Setenv(parts[0], parts[1])
+		defer os.Unsetenv(parts[0])
+	}
+	if err := c.VerifyEnvMapping(o); err != nil {
+		t.Errorf("VerifyEnvMapping(): %v", err)
+	}
+
+	fromEnv, err := NewConfigFromEnv(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fromEnv.ToEnv(o); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToEnv() of config, created from environment: got %v, want %v", got, want)
+	}
+
+	target := NewConfig(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":      "",
+			"max_conns": 0,
+		},
+		"feature_flags": map[string]interface{}{"new_ui": false},
+		"ratio":         0.0,
+		"servers":       []interface{}{},
+	})
+	if err := target.OverrideFromEnv(o); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(target.tree(), tree) {
+		t.Errorf("OverrideFromEnv(): got %v, want %v", target.tree(), tree)
+	}
+}
+
+func TestVerifyEnvMapping(t *testing.T) {
+	tests := []struct {
+		o    EnvOptions
+		tree map[string]interface{}
+		want []string
+	}{
+		{
+			o:    EnvOptions{Prefix: "APP_"},
+			tree: map[string]interface{}{"db": map[string]interface{}{"max_conns": 1, "host": "h"}},
+			want: nil,
+		},
+		{
+			o:    EnvOptions{Prefix: "APP_"},
+			tree: map[string]interface{}{"db": map[string]interface{}{"maxConns": 1}, "ok": 1},
+			want: []string{"db.maxConns env"},
+		},
+		{
+			o:    EnvOptions{Prefix: "APP_"},
+			tree: map[string]interface{}{"a__b": 1, "c": map[string]interface{}{"_d": 2}},
+			want: []string{"a__b env"},
+		},
+		{
+			o:    EnvOptions{Prefix: "APP_", WordSep: "-"},
+			tree: map[string]interface{}{"max-conns": 1, "max_conns": 2},
+			want: []string{"max_conns env"},
+		},
+	}
+	for i, test := range tests {
+		var got []string
+		if err := NewConfig(test.tree).VerifyEnvMapping(test.o); err != nil {
+			problems, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("#%d: ValidationErrors expected, got %v", i, err)
+			}
+			got = problemList(problems)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("#%d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package conf8n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Sentinel errors, wrapped by errors of lookups and Must* methods; check for them with errors.Is()
+var (
+	// Key is not set (or its value is null)
+	ErrNotSet = errors.New("Value is not set")
+	// Value has unexpected type (see also TypeError) or is not one of allowed values; also reported for
+	// values, that can't be stored into destination of Scan()
+	ErrWrongType = errors.New("Value has wrong type")
+	// Destination of Scan() or Decode() is nil (or nil pointer)
+	ErrNilDest = errors.New("Destination is nil")
+	// Destination of Scan() or Decode() is not a pointer
+	ErrNotPointer = errors.New("Destination is not a pointer")
+	// Value is neither slice nor map
+	ErrNotIterable = errors.New("Value is not iterable")
+	// Index of slice element is out of range (lookups of keys with such indices report it instead of ErrNotSet)
+	ErrIndexOutOfRange = errors.New("Index out of range")
+)
+
+// Error of value of unexpected type (use errors.As() to get it). Matches ErrWrongType. Message looks like
+// `config key "db.pool.size": expected int, got string ("ten")`; long values are truncated, values of secret keys
+// (see SecretKeyPattern) are not shown
+type TypeError struct {
+	// Key of value (empty, if unknown)
+	Key string
+	// Expected type, like "int" or "map"
+	Expected string
+	// Kind of actual value (see ValueKind)
+	Actual string
+	// Value itself
+	Value interface{}
+	// Location of the key in source data (if known)
+	Source *Source
+	// key is shown by enclosing error (like ValidationError)
+	nested bool
+}
+
+// Max length of values, shown in error messages; longer values are truncated
+const maxShownLen = 40
+
+func newTypeError(key, expected string, value interface{}) *TypeError {
+	return &TypeError{Key: key, Expected: expected, Actual: kindOf(value).String(), Value: value}
+}
+
+// Returns type error for the value, naming its key and location
+func (v ConfigValue) typeError(expected string) error {
+	err := newTypeError(v.Key(), expected, v.v)
+	if v.c != nil && v.key != "" {
+		if src, ok := v.c.SourceOf(v.key); ok {
+			err.Source = &src
+		}
+	}
+	return err
+}
+
+// Same as newTypeError(), but for errors, that are reported with the key (like ValidationError)
+func kindErr(key string, value interface{}, expected ValueKind) error {
+	err := newTypeError(key, expected.String(), value)
+	err.nested = true
+	return err
+}
+
+func (e *TypeError) Error() string {
+	msg := fmt.Sprintf("expected %s, got %s", e.Expected, e.Actual)
+	if e.Value != nil {
+		msg += fmt.Sprintf(" (%s)", showValue(e.Key, e.Value))
+	}
+	if e.Key == "" || e.nested {
+		return msg
+	}
+	if e.Source != nil {
+		return fmt.Sprintf("config key %q (%s): %s", e.Key, e.Source, msg)
+	}
+	return fmt.Sprintf("config key %q: %s", e.Key, msg)
+}
+
+func (e *TypeError) Is(target error) bool {
+	return target == ErrWrongType
+}
+
+// Returns value for error message: strings are quoted, long values are truncated, values of secret keys
+// (see SecretKeyPattern) are replaced by placeholder. All error messages, that show values, use it
+func showValue(key string, value interface{}) string {
+	if key != "" && SecretKeyPattern.MatchString(key) {
+		return "<redacted>"
+	}
+	s, isStr := value.(string)
+	if !isStr {
+		s = fmt.Sprintf("%v", value)
+	}
+	truncated := false
+	if r := []rune(s); len(r) > maxShownLen {
+		s, truncated = string(r[:maxShownLen]), true
+	}
+	if isStr {
+		s = strconv.Quote(s)
+	}
+	if truncated {
+		s += "..."
+	}
+	return s
+}
+
+// Error of loading of config data (use errors.As() to get it): preprocessing (see WithExpandEnv()) or parsing
+// error, wrapped with description of data source, like `parsing "conf.d/10-db.yaml": yaml: line 37: ...`.
+// Location of the problem is taken from errors of YAML and JSON decoders, where available
+type ParseError struct {
+	// Source of data, like file name or URL (empty, if unknown)
+	Source string
+	// Line and column of the problem (1-based; zero, if unknown)
+	Line int
+	Col  int
+	// Underlying error
+	Err error
+}
+
+// Location of the problem in messages of YAML decoder
+var yamlErrorPosition = regexp.MustCompile(`line (\d+)(?:: column (\d+))?`)
+
+// Returns parsing error of data from given source, locating the problem
+func newParseError(source string, data []byte, err error) *ParseError {
+	e := &ParseError{Source: source, Err: err}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		e.Line, e.Col = offsetPosition(data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		e.Line, e.Col = offsetPosition(data, typeErr.Offset)
+	default:
+		if m := yamlErrorPosition.FindStringSubmatch(err.Error()); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Col, _ = strconv.Atoi(m[2])
+		}
+	}
+	return e
+}
+
+// Returns line and column of the last byte before given offset of data (decoders report offsets after the problem)
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, col := 1, 1
+	for i := int64(0); i < offset-1; i++ {
+		if data[i] == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+func (e *ParseError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("parsing: %v", e.Err)
+	}
+	return fmt.Sprintf("parsing %q: %v", e.Source, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Error with its own message, wrapping sentinel error
+type sentinelError struct {
+	msg      string
+	sentinel error
+}
+
+// Returns error with formatted message, that matches sentinel with errors.Is()
+func wrapf(sentinel error, format string, args ...interface{}) error {
+	return &sentinelError{msg: fmt.Sprintf(format, args...), sentinel: sentinel}
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.sentinel
+}
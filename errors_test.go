@@ -0,0 +1,140 @@
+package conf8n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Values of secret keys must not appear in any error message
+func TestSecretValuesAreRedacted(t *testing.T) {
+	const secret = "hunter2-s3cr3t"
+	c := NewConfig(map[string]interface{}{
+		"db":    map[string]interface{}{"password": secret, "pin_token": 1234567},
+		"limit": 10,
+	})
+	var errs []error
+	for _, s := range []*Schema{
+		func() *Schema { s := NewSchema(); s.Key("db.password", String).MinLen(100); return s }(),
+		func() *Schema { s := NewSchema(); s.Key("db.password", String).MaxLen(3); return s }(),
+		func() *Schema { s := NewSchema(); s.Key("db.password", String).OneOf("a", "b"); return s }(),
+		func() *Schema { s := NewSchema(); s.Key("db.password", String).Pattern("^x$"); return s }(),
+		func() *Schema { s := NewSchema(); s.Key("db.pin_token", Int).Max(10); return s }(),
+		NewSchema().Rule(LessOrEqual("db.pin_token", "limit")),
+	} {
+		errs = append(errs, s.Validate(c))
+	}
+	for _, schema := range []string{
+		`{"properties": {"db": {"properties": {"password": {"minLength": 100}}}}}`,
+		`{"properties": {"db": {"properties": {"password": {"maxLength": 3}}}}}`,
+		`{"properties": {"db": {"properties": {"password": {"enum": ["a", "b"]}}}}}`,
+		`{"properties": {"db": {"properties": {"password": {"const": "a"}}}}}`,
+		`{"properties": {"db": {"properties": {"pin_token": {"maximum": 10}}}}}`,
+	} {
+		if problems := c.ValidateJSONSchema([]byte(schema)); len(problems) > 0 {
+			errs = append(errs, problems)
+		}
+	}
+	var dst struct {
+		OneOf  string `conf8n:"password" validate:"oneof=a b"`
+		Regexp string `conf8n:"password" validate:"regexp=^x$"`
+		Max    int    `conf8n:"pin_token" validate:"max=10"`
+	}
+	db, _ := c.Sub("db")
+	errs = append(errs, db.Decode(&dst))
+	_, err := c.Get("db.password").OneOf("a", "b")
+	errs = append(errs, err)
+	var n int
+	errs = append(errs, c.Get("db.password").Scan(&n))
+	var m map[string]int
+	errs = append(errs, c.Get("db").Scan(&m))
+	_, err = c.Get("db.password").IterateE()
+	errs = append(errs, err)
+
+	if len(errs) != 16 {
+		t.Fatalf("Expected 16 errors, got %d", len(errs))
+	}
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("Case %d: expected error", i)
+		} else if msg := err.Error(); strings.Contains(msg, secret) || strings.Contains(msg, "1234567") {
+			t.Errorf("Case %d: secret value in error message: %s", i, msg)
+		} else if !strings.Contains(msg, "<redacted>") {
+			t.Errorf("Case %d: expected redacted value in error message: %s", i, msg)
+		}
+	}
+}
+
+func TestTypeErrorMessage(t *testing.T) {
+	long := strings.Repeat("x", 50)
+	tests := []struct {
+		key      string
+		expected string
+		value    interface{}
+		want     string
+	}{
+		{"db.pool.size", "int", "ten", `config key "db.pool.size": expected int, got string ("ten")`},
+		{"db.password", "int", "hunter2", `config key "db.password": expected int, got string (<redacted>)`},
+		{"db.name", "string", long, `config key "db.name": expected string, got string ("` + long[:maxShownLen] + `"...)`},
+		{"port", "string", 80, `config key "port": expected string, got int (80)`},
+		{"", "bool", 1.5, `expected bool, got float (1.5)`},
+		{"a", "map", nil, `config key "a": expected map, got nil`},
+	}
+	for _, tt := range tests {
+		err := newTypeError(tt.key, tt.expected, tt.value)
+		if got := err.Error(); got != tt.want {
+			t.Errorf("newTypeError(%q, %q, %v):\ngot  %s\nwant %s", tt.key, tt.expected, tt.value, got, tt.want)
+		}
+		if !errors.Is(err, ErrWrongType) {
+			t.Errorf("newTypeError(%q, ...) doesn't match ErrWrongType", tt.key)
+		}
+	}
+}
+
+func TestShowValue(t *testing.T) {
+	tests := []struct {
+		key   string
+		value interface{}
+		want  string
+	}{
+		{"name", "abc", `"abc"`},
+		{"api_key", "abc", "<redacted>"},
+		{"auth.Token", 42, "<redacted>"},
+		{"", "a\nb", `"a\nb"`},
+		{"list", []interface{}{1, 2}, "[1 2]"},
+		{"name", strings.Repeat("я", 41), `"` + strings.Repeat("я", 40) + `"...`},
+	}
+	for _, tt := range tests {
+		if got := showValue(tt.key, tt.value); got != tt.want {
+			t.Errorf("showValue(%q, %v): got %s, want %s", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"mode": "test", "port": 80, "list": []interface{}{}})
+	var n int
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+	}{
+		{"oneOf", func() error { _, err := c.Get("mode").OneOf("dev", "prod"); return err }(), ErrWrongType},
+		{"finished iterator", func() error { _, err := c.Get("list").Iterate().Section(); return err }(), ErrNotSet},
+		{"scan into nil", c.Get("port").Scan(nil), ErrNilDest},
+		{"scan into non-pointer", c.Get("port").Scan(n), ErrNotPointer},
+		{"scan into nil pointer", c.Get("port").Scan((*int)(nil)), ErrNilDest},
+		{"scan of incompatible value", c.Get("mode").Scan(&n), ErrWrongType},
+		{"scan into unsupported type", c.Get("port").Scan(new(chan int)), ErrWrongType},
+		{"decode into nil", c.Decode(nil), ErrNilDest},
+		{"decode into non-pointer", c.Decode(n), ErrNotPointer},
+		{"set under scalar", c.Set("port.number", 1), ErrWrongType},
+		{"reference to unset key", NewConfig(map[string]interface{}{"a": "${missing}"}).Resolve(true), ErrNotSet},
+		{"reference to non-scalar", NewConfig(map[string]interface{}{"a": "${b}", "b": []interface{}{}}).Resolve(true), ErrWrongType},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.sentinel) {
+			t.Errorf("%s: error %v doesn't match %v", tt.name, tt.err, tt.sentinel)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package conf8n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Adapter, binding config key to command line flag. Implements flag.Value and flag.Getter
+type ConfigFlag struct {
+	c   *Config
+	key string
+}
+
+// Creates flag.Value, backed by config key. Current config value is shown as flag's default,
+// and value, given in command line, is written back to config:
+//
+//	flag.Var(conf8n.FlagValue(conf, "db.host"), "db-host", "database host")
+//
+// Type of the value, set in config, is preserved: for example, "8080" set for int-typed key will be stored as int
+func FlagValue(c *Config, key string) *ConfigFlag {
+	return &ConfigFlag{c: c, key: key}
+}
+
+// Returns string representation of current config value
+func (f *ConfigFlag) String() string {
+	if f == nil || f.c == nil {
+		return ""
+	}
+	v, _ := f.c.lookup(f.key)
+	return formatPlain(v)
+}
+
+// Formats value as plain string: slices are given as comma-separated lists, null as empty string
+func formatPlain(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if a, ok := v.([]interface{}); ok {
+		strs := make([]string, len(a))
+		for i, el := range a {
+			strs[i] = fmt.Sprint(el)
+		}
+		return strings.Join(strs, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// Parses given string according to type of current config value and stores the result in config
+func (f *ConfigFlag) Set(s string) error {
+	current, _ := f.c.lookup(f.key)
+	value, err := parseLike(current, s)
+	if err != nil {
+		return fmt.Errorf("Key '%s': %w", f.key, err)
+	}
+	return f.c.Set(f.key, value)
+}
+
+// Parses string to the type of current value (int, float, bool or slice of strings, given as comma-separated list).
+// Other strings are returned as is
+func parseLike(current interface{}, s string) (interface{}, error) {
+	var value interface{} = s
+	var err error
+	switch current.(type) {
+	case int:
+		value, err = strconv.Atoi(s)
+	case float64:
+		value, err = strconv.ParseFloat(s, 64)
+	case bool:
+		value, err = strconv.ParseBool(s)
+	case []interface{}:
+		parts := strings.Split(s, ",")
+		a := make([]interface{}, len(parts))
+		for i, p := range parts {
+			a[i] = strings.TrimSpace(p)
+		}
+		value = a
+	}
+	return value, err
+}
+
+// Returns current config value (as interface{})
+func (f *ConfigFlag) Get() interface{} {
+	v, _ := f.c.lookup(f.key)
+	return v
+}
+
+// Allows to use bool flags without value (e.g. "-debug"), when config value is bool
+func (f *ConfigFlag) IsBoolFlag() bool {
+	v, _ := f.c.lookup(f.key)
+	_, is := v.(bool)
+	return is
+}
@@ -0,0 +1,58 @@
+package conf8n
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFlagValuePreservesType(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"db":      map[string]interface{}{"host": "localhost", "port": 5432, "timeout": 1.5, "tls": false},
+		"hosts":   []interface{}{"a"},
+		"nothing": nil,
+	})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for key, name := range map[string]string{
+		"db.host": "db-host", "db.port": "db-port", "db.timeout": "db-timeout", "db.tls": "db-tls",
+		"hosts": "hosts", "nothing": "nothing", "missing": "missing",
+	} {
+		fs.Var(FlagValue(c, key), name, "")
+	}
+	if def := fs.Lookup("db-port").DefValue; def != "5432" {
+		t.Errorf("Default of int flag: got %q", def)
+	}
+	if def := fs.Lookup("hosts").DefValue; def != "a" {
+		t.Errorf("Default of slice flag: got %q", def)
+	}
+	err := fs.Parse([]string{"-db-host", "db.local", "-db-port", "8080", "-db-timeout", "3", "-db-tls",
+		"-hosts", "b, c", "-nothing", "42", "-missing", "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"db.host": "db.local", "db.port": 8080, "db.timeout": 3.0, "db.tls": true,
+		"hosts": []interface{}{"b", "c"}, "nothing": "42", "missing": "true",
+	} {
+		if got := c.Get(key).Raw(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Key '%s': got %#v, want %#v", key, got, want)
+		}
+	}
+	if got := fs.Lookup("db-port").Value.(flag.Getter).Get(); got != 8080 {
+		t.Errorf("Get(): got %#v", got)
+	}
+}
+
+func TestFlagValueRejectsWrongType(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"port": 5432, "tls": false})
+	for key, s := range map[string]string{"port": "80a", "tls": "maybe"} {
+		if err := FlagValue(c, key).Set(s); err == nil {
+			t.Errorf("Key '%s': expected error for %q", key, s)
+		}
+	}
+	if c.Get("port").Int() != 5432 || c.Get("tls").Bool() {
+		t.Error("Values are not expected to change")
+	}
+}
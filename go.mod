@@ -0,0 +1,10 @@
+module github.com/safronizator/conf8n
+
+go 1.16
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/sys v0.13.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,114 @@
+package conf8n
+
+import (
+	"sync"
+	"time"
+)
+
+// Number of reload events, kept by Live holder by default (see Live.History())
+const DefaultHistorySize = 32
+
+// Record about attempt of config update in Live holder
+type ReloadEvent struct {
+	Time time.Time
+	// File, URL or signal, that caused reloading (empty for direct Live.Update() and Live.Store() calls)
+	Source string
+	// Error of loading or validation (empty string for successful updates)
+	Error string
+	// Number of added, removed and changed leaf keys (for successful updates)
+	ChangedKeys int
+	// Checksum of candidate config (see Config.Checksum()), if it was loaded
+	Checksum string
+}
+
+// Counters of update attempts in Live holder, suitable for exporting as metrics
+type ReloadStats struct {
+	Updates  uint64
+	Failures uint64
+}
+
+// Ring buffer of reload events with counters. Recording doesn't block readers of Live.Load()
+type reloadHistory struct {
+	mu       sync.Mutex
+	size     int
+	events   []ReloadEvent
+	next     int
+	updates  uint64
+	failures uint64
+}
+
+func (h *reloadHistory) add(t time.Time, source string, prev, next *Config, err error) {
+	ev := ReloadEvent{Time: t, Source: source}
+	if next != nil {
+		ev.Checksum = next.Checksum()
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	} else {
+		var diff []string
+		diffTrees(dataOf(prev), dataOf(next), "", SEP, &diff)
+		ev.ChangedKeys = len(diff)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.failures++
+	} else {
+		h.updates++
+	}
+	if h.size <= 0 {
+		return
+	}
+	if len(h.events) < h.size {
+		h.events = append(h.events, ev)
+		return
+	}
+	h.events[h.next] = ev
+	h.next = (h.next + 1) % h.size
+}
+
+// Returns data of config, which may be nil
+func dataOf(c *Config) interface{} {
+	if c == nil {
+		return map[string]interface{}{}
+	}
+	return c.tree()
+}
+
+// Sets number of reload events, kept by holder (see History()), and returns holder itself.
+// Zero size disables recording of events (counters of Stats() are still updated)
+func (l *Live) WithHistorySize(size int) *Live {
+	if size < 0 {
+		size = 0
+	}
+	h := &l.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	events := l.historyLocked()
+	if len(events) > size {
+		events = events[len(events)-size:]
+	}
+	h.size, h.events, h.next = size, events, 0
+	return l
+}
+
+// Returns recorded update attempts (the oldest first). Number of kept events is limited (see WithHistorySize())
+func (l *Live) History() []ReloadEvent {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	return l.historyLocked()
+}
+
+func (l *Live) historyLocked() []ReloadEvent {
+	h := &l.history
+	events := make([]ReloadEvent, 0, len(h.events))
+	events = append(events, h.events[h.next:]...)
+	return append(events, h.events[:h.next]...)
+}
+
+// Returns counters of successful and failed update attempts
+func (l *Live) Stats() ReloadStats {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	return ReloadStats{Updates: l.history.updates, Failures: l.history.failures}
+}
@@ -0,0 +1,86 @@
+package conf8n
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLiveHistory(t *testing.T) {
+	l := NewLive(nil).WithValidator(func(c *Config) error {
+		if !c.Has("v") {
+			return errors.New("v is not set")
+		}
+		return nil
+	})
+	first := NewConfig(map[string]interface{}{"v": 1, "a": 1})
+	second := NewConfig(map[string]interface{}{"v": 2, "b": 1})
+	l.Store(first)
+	if err := l.Update(second); err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Update(NewConfig(map[string]interface{}{}))
+	l.reload("config.yaml", nil, errors.New("parse error"))
+	want := []ReloadEvent{
+		{ChangedKeys: 2, Checksum: first.Checksum()},
+		// v is changed, a is removed, b is added
+		{ChangedKeys: 3, Checksum: second.Checksum()},
+		{Error: "v is not set", Checksum: NewConfig(map[string]interface{}{}).Checksum()},
+		{Source: "config.yaml", Error: "parse error"},
+	}
+	events := l.History()
+	if len(events) != len(want) {
+		t.Fatalf("Got %d events, want %d", len(events), len(want))
+	}
+	for i, ev := range events {
+		if ev.Time.IsZero() || i > 0 && ev.Time.Before(events[i-1].Time) {
+			t.Errorf("Event %d: unexpected time %v", i, ev.Time)
+		}
+		ev.Time = want[i].Time
+		if ev != want[i] {
+			t.Errorf("Event %d: got %+v, want %+v", i, ev, want[i])
+		}
+	}
+	if stats := l.Stats(); stats != (ReloadStats{Updates: 2, Failures: 2}) {
+		t.Errorf("Got stats %+v", stats)
+	}
+}
+
+func TestLiveHistorySize(t *testing.T) {
+	l := NewLive(nil).WithHistorySize(3)
+	for i := 0; i < 5; i++ {
+		l.Store(NewConfig(map[string]interface{}{"v": i}))
+	}
+	checksums := func() []string {
+		var res []string
+		for _, ev := range l.History() {
+			res = append(res, ev.Checksum)
+		}
+		return res
+	}
+	expect := func(step string, values ...int) {
+		got := checksums()
+		if len(got) != len(values) {
+			t.Fatalf("%s: got %d events, want %d", step, len(got), len(values))
+		}
+		for i, v := range values {
+			if want := NewConfig(map[string]interface{}{"v": v}).Checksum(); got[i] != want {
+				t.Errorf("%s: event %d is expected to be update to v=%d", step, i, v)
+			}
+		}
+	}
+	expect("ring buffer", 2, 3, 4)
+	l.WithHistorySize(2)
+	expect("shrinking", 3, 4)
+	l.WithHistorySize(4)
+	l.Store(NewConfig(map[string]interface{}{"v": 5}))
+	expect("growing", 3, 4, 5)
+	l.WithHistorySize(0)
+	l.Store(NewConfig(map[string]interface{}{"v": 6}))
+	expect("disabled")
+	if stats := l.Stats(); stats.Updates != 7 {
+		t.Errorf("Counters are expected to be updated with disabled history, got %+v", stats)
+	}
+	if got := len(NewLive(nil).WithHistorySize(-1).History()); got != 0 {
+		t.Errorf("Got %d events", got)
+	}
+}
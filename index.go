@@ -0,0 +1,63 @@
+package conf8n
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Flat index of leaf values of config, keyed by composite keys. Shared by config and its sub-configs,
+// so changes made through any of them invalidate it
+type keyIndex struct {
+	mu     sync.RWMutex
+	owner  *Config
+	values map[string]interface{}
+}
+
+// Enables lookups by index and returns config itself. Index maps composite keys of all leaf values (including
+// elements of slices, like "servers.0.host") to the values, so lookups of them don't traverse the tree; other
+// lookups (of sections, with negative indices, etc) work as usual. Index is built on the first lookup and rebuilt
+// after changes of config (Set(), Delete(), Merge(), ExpandEnv() and others), made through config itself or its
+// sub-configs, created after the call. Sub-configs don't use index of their parent, but can be indexed themselves.
+// Index is not used by case-insensitive configs
+func (c *Config) Index() *Config {
+	c.index = &keyIndex{owner: c}
+	return c
+}
+
+// Looks up leaf value in index
+func (c *Config) indexed(key string) (interface{}, bool) {
+	idx := c.index
+	if idx == nil || idx.owner != c || c.foldCase {
+		return nil, false
+	}
+	idx.mu.RLock()
+	values := idx.values
+	idx.mu.RUnlock()
+	if values == nil {
+		idx.mu.Lock()
+		if idx.values == nil {
+			idx.values = make(map[string]interface{})
+			// leaves, nested deeper than MaxDepth, are looked up without index
+			_ = walkLeaves(c.tree(), "", c.separator(), true, func(path string, v interface{}) {
+				idx.values[path] = v
+			})
+		}
+		values = idx.values
+		idx.mu.Unlock()
+	}
+	v, found := values[key]
+	return v, found
+}
+
+// Drops index (if any) after change of config data and counts the change, so lookup caches of config,
+// its parents and sub-configs (see Cache()) are dropped too
+func (c *Config) invalidate() {
+	if idx := c.index; idx != nil {
+		idx.mu.Lock()
+		idx.values = nil
+		idx.mu.Unlock()
+	}
+	if c.changes != nil {
+		atomic.AddUint64(c.changes, 1)
+	}
+}
@@ -0,0 +1,65 @@
+package conf8n
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Returns tree of given depth, where every section has width keys ("k0", "k1", ...); leaves are ints
+func nestedTree(depth, width int) map[string]interface{} {
+	m := make(map[string]interface{}, width)
+	for i := 0; i < width; i++ {
+		if depth > 1 {
+			m[fmt.Sprint("k", i)] = nestedTree(depth-1, width)
+		} else {
+			m[fmt.Sprint("k", i)] = i
+		}
+	}
+	return m
+}
+
+// Returns key of leaf of nestedTree() with given count of segments
+func nestedKey(segments int) string {
+	parts := make([]string, segments)
+	for i := range parts {
+		parts[i] = fmt.Sprint("k", i%4)
+	}
+	return strings.Join(parts, SEP)
+}
+
+func TestIndexIsRebuiltAfterChanges(t *testing.T) {
+	c := NewConfig(nestedTree(3, 2)).Index()
+	if v := c.Get("k1.k0.k1").Int(); v != 1 {
+		t.Fatalf("Got %d", v)
+	}
+	if err := c.Set("k1.k0.k1", 10); err != nil {
+		t.Fatal(err)
+	}
+	c.Merge(NewConfig(map[string]interface{}{"k0": map[string]interface{}{"k1": "merged"}}), "")
+	for key, want := range map[string]interface{}{"k1.k0.k1": 10, "k0.k1": "merged", "k0.k1.k0": nil, "k1.k1.k0": 0} {
+		if got := c.Get(key).Raw(); got != want {
+			t.Errorf("Key '%s': got %v, want %v", key, got, want)
+		}
+	}
+}
+
+func BenchmarkGetDeepIndexed(b *testing.B) {
+	key := nestedKey(6)
+	for _, indexed := range []bool{false, true} {
+		b.Run(fmt.Sprint("indexed=", indexed), func(b *testing.B) {
+			c := NewConfig(nestedTree(6, 4))
+			if indexed {
+				c.Index()
+			}
+			if !c.Get(key).IsSet() {
+				b.Fatalf("Key %s is not set", key)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Get(key)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package conf8n
+
+// Deduplicates strings of config data: equal map keys and string values (including elements of slices) are made
+// to share memory, so configs with thousands of similar entries (like per-route settings) keep single copy of
+// every distinct string. Data is changed in place (values stay equal, so sub-configs and index stay valid)
+// and config itself is returned. See also WithInterning()
+func (c *Config) Intern() *Config {
+	c.detach()
+	internTree(c.tree(), make(map[string]string))
+	return c
+}
+
+// Returns interned copy of string
+func internString(s string, pool map[string]string) string {
+	if interned, found := pool[s]; found {
+		return interned
+	}
+	pool[s] = s
+	return s
+}
+
+// Interns strings of tree in place and returns node with interned strings
+func internTree(node interface{}, pool map[string]string) interface{} {
+	switch n := node.(type) {
+	case string:
+		return internString(n, pool)
+	case map[string]interface{}:
+		for k, v := range n {
+			// assigning to existing key replaces stored key with the interned one
+			n[internString(k, pool)] = internTree(v, pool)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			if ks, isStr := k.(string); isStr {
+				k = internString(ks, pool)
+			}
+			n[k] = internTree(v, pool)
+		}
+	case []interface{}:
+		for i, v := range n {
+			n[i] = internTree(v, pool)
+		}
+	}
+	return node
+}
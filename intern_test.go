@@ -0,0 +1,77 @@
+package conf8n
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Returns YAML document with given count of similar entries
+func similarEntriesYaml(count int) []byte {
+	var b strings.Builder
+	b.WriteString("routes:\n")
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&b, "  - {path: /api/v1/resource-%d, method: GET, backend: backend-%d.internal, auth: required}\n", i, i%10)
+	}
+	return []byte(b.String())
+}
+
+func TestIntern(t *testing.T) {
+	data := similarEntriesYaml(100)
+	plain, err := NewConfigFromYaml(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interned, err := NewConfigFromYaml(data, WithInterning())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plain.tree(), interned.tree()) {
+		t.Error("Interning is not expected to change data")
+	}
+	if !reflect.DeepEqual(plain.Intern().tree(), interned.tree()) {
+		t.Error("Intern() is not expected to change data")
+	}
+}
+
+// Returns size of heap, retained by config, loaded by given function
+func retainedHeap(load func() *Config) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	c := load()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(c)
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+// Builds config with 50k entries with and without interning, reporting heap, retained by its data
+// (layout of YAML document, kept by NewConfigFromYaml(), is not interned, so data is decoded separately)
+func BenchmarkInterning(b *testing.B) {
+	data := similarEntriesYaml(50000)
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprint("intern=", intern), func(b *testing.B) {
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				retained += retainedHeap(func() *Config {
+					m := make(map[string]interface{})
+					if err := yaml.Unmarshal(data, &m); err != nil {
+						b.Fatal(err)
+					}
+					normalizeTree(m)
+					c := NewConfig(m)
+					if intern {
+						c.Intern()
+					}
+					return c
+				})
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package conf8n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Substitutes environment variables in all string values of config (including nested into maps and slices).
+// Supported forms follow POSIX shells:
+//   - $VAR and ${VAR} are replaced by value of VAR;
+//   - ${VAR:-default} gives default when VAR is unset or empty, ${VAR-default} - only when VAR is unset;
+//   - ${VAR:?message} fails with message when VAR is unset or empty, ${VAR?message} - only when VAR is unset.
+//
+// Defaults and messages may contain references themselves (like ${HOST:-${FALLBACK_HOST}}); they are expanded
+// only when used. References, that are not variables (like ${secret:ref} or ${dotted.key}), are kept as is.
+// "$$" produces literal "$". Failed ${VAR:?...} reference is always reported as error;
+// if strict is true, reference to unset variable without default is reported as error too, otherwise
+// it is replaced by empty string. On error, config stays unchanged from the failed value on
+func (c *Config) ExpandEnv(strict bool) error {
+	return c.expand(EnvVars(), strict, "environment variables")
+}
+
+// Source of variable values for Config.Expand(). Returns false, if variable is not set
+type VarMapping func(name string) (string, bool)
+
+// Returns mapping, giving environment variables
+func EnvVars() VarMapping {
+	return os.LookupEnv
+}
+
+// Returns mapping, giving values from map
+func VarsFromMap(m map[string]string) VarMapping {
+	return func(name string) (string, bool) {
+		val, found := m[name]
+		return val, found
+	}
+}
+
+// Returns mapping, trying given mappings in order until some of them has the variable set:
+//
+//	config.Expand(conf8n.ChainVars(flagVars, conf8n.EnvVars()), true)
+func ChainVars(mappings ...VarMapping) VarMapping {
+	return func(name string) (string, bool) {
+		for _, m := range mappings {
+			if val, set := m(name); set {
+				return val, true
+			}
+		}
+		return "", false
+	}
+}
+
+// Substitutes variables, given by mapping, in all string values of config (including nested into maps and slices).
+// Syntax and handling of unset variables are the same, as for ExpandEnv() (which is Expand() with EnvVars() mapping)
+func (c *Config) Expand(mapping VarMapping, strict bool) error {
+	return c.expand(mapping, strict, "variables")
+}
+
+func (c *Config) expand(mapping VarMapping, strict bool, what string) error {
+	c.detach()
+	defer c.invalidate()
+	_, err := transformStrings(c.tree(), "", c.separator(), func(path, s string) (string, error) {
+		expanded, missing, err := expandVars(s, mapping)
+		if err != nil {
+			return "", fmt.Errorf("Key '%s': %w", path, err)
+		}
+		if strict && len(missing) > 0 {
+			return "", fmt.Errorf("Key '%s': %s not set: %s", path, what, strings.Join(missing, ", "))
+		}
+		return expanded, nil
+	})
+	return err
+}
+
+// Substitutes variables, given by lookup, in string (see ExpandEnv() for syntax).
+// Returns names of unset variables without defaults along with result
+func expandVars(s string, lookup VarMapping) (string, []string, error) {
+	e := &varExpander{lookup: lookup, seen: make(map[string]bool)}
+	res, err := e.expand(s)
+	if err != nil {
+		return "", nil, err
+	}
+	return res, e.missing, nil
+}
+
+type varExpander struct {
+	lookup  VarMapping
+	missing []string
+	seen    map[string]bool
+}
+
+func (e *varExpander) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		switch {
+		case s[0] == '$':
+			b.WriteByte('$')
+			s = s[1:]
+		case s[0] == '{':
+			end := matchingBrace(s)
+			if end < 0 {
+				// unterminated reference is kept as is
+				b.WriteByte('$')
+				b.WriteString(s)
+				return b.String(), nil
+			}
+			val, err := e.expandBraced(s[1:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(val)
+			s = s[end+1:]
+		default:
+			n := varNameLen(s)
+			if n == 0 {
+				b.WriteByte('$')
+				continue
+			}
+			b.WriteString(e.value(s[:n]))
+			s = s[n:]
+		}
+	}
+}
+
+// Expands contents of ${...} reference. References, that don't start with variable name, or have something else,
+// than operator, after it (like ${secret:ref} or ${dotted.key}, handled by ResolveSecrets() and Resolve()),
+// are kept as is
+func (e *varExpander) expandBraced(ref string) (string, error) {
+	n := varNameLen(ref)
+	name, op := ref[:n], ref[n:]
+	var word string
+	switch {
+	case n == 0:
+		return "${" + ref + "}", nil
+	case op == "":
+		return e.value(name), nil
+	case strings.HasPrefix(op, ":-"), strings.HasPrefix(op, ":?"):
+		op, word = op[:2], op[2:]
+	case strings.HasPrefix(op, "-"), strings.HasPrefix(op, "?"):
+		op, word = op[:1], op[1:]
+	default:
+		return "${" + ref + "}", nil
+	}
+	val, set := e.lookup(name)
+	if set && (val != "" || op[0] != ':') {
+		return val, nil
+	}
+	if op == "-" || op == ":-" {
+		return e.expand(word)
+	}
+	msg, err := e.expand(word)
+	if err != nil {
+		return "", err
+	}
+	if msg == "" {
+		if op == "?" {
+			msg = "parameter not set"
+		} else {
+			msg = "parameter null or not set"
+		}
+	}
+	return "", fmt.Errorf("%s: %s", name, msg)
+}
+
+// Returns value of variable, remembering it as missing, if it is unset
+func (e *varExpander) value(name string) string {
+	val, set := e.lookup(name)
+	if !set && !e.seen[name] {
+		e.seen[name] = true
+		e.missing = append(e.missing, name)
+	}
+	return val
+}
+
+// Returns length of variable name at the beginning of string
+func varNameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// Returns index of brace, closing the one at the beginning of string, or -1. Takes nested ${...} into account
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '{' && (i == 0 || s[i-1] == '$'):
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Substitutes references to other keys, written as ${dotted.key}, in all string values of config
+// with stringified values of referenced keys. References are resolved recursively; reference cycles
+// are reported as error. "$${...}" is not a reference and is kept as is, so ExpandEnv(), that must be called after
+// Resolve() (as it uses the same ${...} syntax), turns it into literal "${...}". References to secrets and variables
+// with defaults or messages (like ${secret:db} or ${PORT:-8080}) are kept for ResolveSecrets() and ExpandEnv(),
+// unless config has such key. Referenced value must be a scalar.
+// If strict is true, reference to missing key is reported as error; otherwise it is replaced by empty string
+func (c *Config) Resolve(strict bool) error {
+	r := &refResolver{c: c, strict: strict, resolved: make(map[string]string)}
+	c.detach()
+	defer c.invalidate()
+	results := make(map[string]string)
+	if _, err := transformStrings(c.tree(), "", c.separator(), func(path, s string) (string, error) {
+		res, err := r.resolveString(s, []string{path})
+		results[path] = res
+		return s, err
+	}); err != nil {
+		return err
+	}
+	_, err := transformStrings(c.tree(), "", c.separator(), func(path, s string) (string, error) {
+		return results[path], nil
+	})
+	return err
+}
+
+type refResolver struct {
+	c        *Config
+	strict   bool
+	resolved map[string]string
+}
+
+func (r *refResolver) resolveString(s string, chain []string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			// escapes are removed by ExpandEnv()
+			b.WriteString(s[:i+2])
+			s = s[i+2:]
+			continue
+		}
+		end := matchingBrace(s[i+1:])
+		if end < 0 {
+			return "", fmt.Errorf("Key '%s': unterminated reference in %s", chain[0], showValue(chain[0], s))
+		}
+		end += i + 1
+		b.WriteString(s[:i])
+		if ref := s[i+2 : end]; r.isKeyRef(ref) {
+			val, err := r.resolveKey(ref, chain)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(val)
+		} else {
+			b.WriteString(s[i : end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// Checks if contents of ${...} reference is a key. References to secrets (${secret:...}), variables with
+// shell operators (like ${PORT:-8080}, see ExpandEnv()) and references with nested ones are not keys,
+// unless config has such key
+func (r *refResolver) isKeyRef(ref string) bool {
+	if _, found := r.c.lookup(ref); found {
+		return true
+	}
+	if strings.HasPrefix(ref, "secret:") || strings.Contains(ref, "$") {
+		return false
+	}
+	n := varNameLen(ref)
+	for _, op := range []string{":-", ":?", "-", "?"} {
+		if n > 0 && strings.HasPrefix(ref[n:], op) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *refResolver) resolveKey(key string, chain []string) (string, error) {
+	if res, ok := r.resolved[key]; ok {
+		return res, nil
+	}
+	for _, k := range chain {
+		if k == key {
+			return "", fmt.Errorf("Reference cycle: %s -> %s", strings.Join(chain, " -> "), key)
+		}
+	}
+	v, found := r.c.lookup(key)
+	if !found || v == nil {
+		if r.strict {
+			return "", wrapf(ErrNotSet, "Key '%s': referenced key '%s' is not set", chain[0], key)
+		}
+		return "", nil
+	}
+	var res string
+	switch kindOf(v) {
+	case String:
+		var err error
+		if res, err = r.resolveString(v.(string), append(chain, key)); err != nil {
+			return "", err
+		}
+	case Bool, Int, Float, Time:
+		res = fmt.Sprint(v)
+	default:
+		return "", wrapf(ErrWrongType, "Key '%s': referenced key '%s' is a %s, not a scalar", chain[0], key, kindOf(v))
+	}
+	r.resolved[key] = res
+	return res, nil
+}
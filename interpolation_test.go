@@ -0,0 +1,240 @@
+package conf8n
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	vars := VarsFromMap(map[string]string{"SET": "val", "EMPTY": "", "DEF": "fallback", "PORT": "8080"})
+	tests := []struct {
+		in, want string
+		missing  []string
+		err      string
+	}{
+		// plain references
+		{"$SET", "val", nil, ""},
+		{"${SET}", "val", nil, ""},
+		{"${SET}x $SET.x", "valx val.x", nil, ""},
+		{"$EMPTY|${EMPTY}", "|", nil, ""},
+		{"$UNSET|${UNSET}|$UNSET", "||", []string{"UNSET"}, ""},
+		{"no refs", "no refs", nil, ""},
+		// ":-" - default for unset or empty, "-" - only for unset
+		{"${SET:-d}", "val", nil, ""},
+		{"${EMPTY:-d}", "d", nil, ""},
+		{"${UNSET:-d}", "d", nil, ""},
+		{"${SET-d}", "val", nil, ""},
+		{"${EMPTY-d}", "", nil, ""},
+		{"${UNSET-d}", "d", nil, ""},
+		{"${UNSET:-}", "", nil, ""},
+		{"${UNSET:-a b:c}", "a b:c", nil, ""},
+		// nested references in defaults are expanded only when used
+		{"${UNSET:-${DEF}}", "fallback", nil, ""},
+		{"${UNSET:-${OTHER:-${PORT}}}", "8080", nil, ""},
+		{"${SET:-${OTHER}}", "val", nil, ""},
+		{"${UNSET:-${OTHER}}", "", []string{"OTHER"}, ""},
+		{"${UNSET:-{x}}", "{x}", nil, ""},
+		{"${UNSET:-$$}", "$", nil, ""},
+		// ":?" - error for unset or empty, "?" - only for unset
+		{"${SET:?msg}", "val", nil, ""},
+		{"${SET?msg}", "val", nil, ""},
+		{"${EMPTY?msg}", "", nil, ""},
+		{"${EMPTY:?msg}", "", nil, "EMPTY: msg"},
+		{"${UNSET:?database password required}", "", nil, "UNSET: database password required"},
+		{"${UNSET?msg}", "", nil, "UNSET: msg"},
+		{"${UNSET:?}", "", nil, "UNSET: parameter null or not set"},
+		{"${UNSET?}", "", nil, "UNSET: parameter not set"},
+		{"${UNSET:?no ${DEF}}", "", nil, "UNSET: no fallback"},
+		// escapes and references, that are not variables
+		{"$$SET", "$SET", nil, ""},
+		{"$${SET:-d}", "${SET:-d}", nil, ""},
+		{"${secret:db}", "${secret:db}", nil, ""},
+		{"${a.b}", "${a.b}", nil, ""},
+		{"${SET+x}", "${SET+x}", nil, ""},
+		{"${}", "${}", nil, ""},
+		{"${UNSET:-x", "${UNSET:-x", nil, ""},
+		{"price: 5$", "price: 5$", nil, ""},
+		{"$-", "$-", nil, ""},
+	}
+	for _, tt := range tests {
+		got, missing, err := expandVars(tt.in, vars)
+		switch {
+		case tt.err != "":
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%q: got error %v, want %q", tt.in, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%q: unexpected error %v", tt.in, err)
+		case got != tt.want || !reflect.DeepEqual(missing, tt.missing):
+			t.Errorf("%q: got %q (missing %v), want %q (missing %v)", tt.in, got, missing, tt.want, tt.missing)
+		}
+	}
+	_, err := NewConfigFromYaml([]byte("db: {pass: '${CONF8N_TEST_UNSET:?database password required}'}"), WithExpandEnv(false))
+	if err == nil || !strings.Contains(err.Error(), "CONF8N_TEST_UNSET: database password required") {
+		t.Errorf("Loading is expected to fail with message of reference, got %v", err)
+	}
+}
+
+func TestWithExpandEnvKeepsOtherReferences(t *testing.T) {
+	os.Setenv("CONF8N_TEST_HOST", "db.local")
+	defer os.Unsetenv("CONF8N_TEST_HOST")
+	data := []byte("host: ${CONF8N_TEST_HOST}\npassword: ${secret:prod/db}\nurl: http://${server.host}/\n")
+	for _, strict := range []bool{false, true} {
+		c, err := NewConfigFromYaml(data, WithExpandEnv(strict))
+		if err != nil {
+			t.Fatalf("strict %v: %v", strict, err)
+		}
+		want := map[string]string{"host": "db.local", "password": "${secret:prod/db}", "url": "http://${server.host}/"}
+		for k, w := range want {
+			if got := c.Get(k).String(); got != w {
+				t.Errorf("strict %v, %s: got %q, want %q", strict, k, got, w)
+			}
+		}
+	}
+}
+
+func TestEscapesSurviveAllPasses(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"host": "db.local",
+		"lit":  "$${lit}",
+		"ref":  "${host}:$${host}",
+		"sec":  "${secret:db}/$${secret:db}",
+	}).WithSecretResolver(SecretMap{"db": "s3cr3t"})
+	if err := c.ResolveSecrets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Resolve(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Expand(VarsFromMap(map[string]string{"lit": "expanded", "host": "expanded"}), true); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"lit": "${lit}", "ref": "db.local:${host}", "sec": "s3cr3t/${secret:db}"}
+	for k, w := range want {
+		if got := c.Get(k).String(); got != w {
+			t.Errorf("%s: got %q, want %q", k, got, w)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	data := func() map[string]interface{} {
+		return map[string]interface{}{
+			"host":    "db.local",
+			"port":    5432,
+			"debug":   true,
+			"url":     "postgres://${host}:${port}/app",
+			"chain":   "[${url}]",
+			"db-name": "app",
+			"section": map[string]interface{}{"a": 1},
+			"list":    []interface{}{1},
+		}
+	}
+	tests := []struct {
+		value  string
+		strict bool
+		want   string
+		err    error
+	}{
+		{"${host}", true, "db.local", nil},
+		{"${port}/${debug}", true, "5432/true", nil},
+		{"${chain}", true, "[postgres://db.local:5432/app]", nil},
+		{"${section.a}", true, "1", nil},
+		{"${db-name}", true, "app", nil},
+		// escapes are kept for ExpandEnv()
+		{"$${host}", true, "$${host}", nil},
+		{"$${host}:${host}", true, "$${host}:db.local", nil},
+		// references, that are not keys, are kept
+		{"${DB_HOST:-localhost}", true, "${DB_HOST:-localhost}", nil},
+		{"${DB_HOST-localhost}", true, "${DB_HOST-localhost}", nil},
+		{"${DB_PASS:?required}", true, "${DB_PASS:?required}", nil},
+		{"${A:-${B}}/${host}", true, "${A:-${B}}/db.local", nil},
+		{"${secret:prod/db}", true, "${secret:prod/db}", nil},
+		// missing keys
+		{"${missing}", false, "", nil},
+		{"<${missing}>", false, "<>", nil},
+		{"${missing}", true, "", ErrNotSet},
+		// non-scalars
+		{"${section}", true, "", ErrWrongType},
+		{"${list}", false, "", ErrWrongType},
+	}
+	for _, tt := range tests {
+		m := data()
+		m["value"] = tt.value
+		c := NewConfig(m)
+		err := c.Resolve(tt.strict)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%q: got error %v, want %v", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.value, err)
+		} else if got := c.Get("value").String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveCycles(t *testing.T) {
+	for _, data := range []map[string]interface{}{
+		{"a": "${a}"},
+		{"a": "${b}", "b": "${a}"},
+		{"a": "x${b}", "b": "${c}", "c": "y${a}"},
+		{"a": map[string]interface{}{"b": "${c}"}, "c": "${a.b}"},
+	} {
+		c := NewConfig(data)
+		err := c.Resolve(false)
+		if err == nil || !strings.Contains(err.Error(), "Reference cycle") {
+			t.Errorf("%v: expected reference cycle, got %v", data, err)
+		}
+	}
+	c := NewConfig(map[string]interface{}{"a": "${b}${b}", "b": "${c}", "c": "x"})
+	if err := c.Resolve(true); err != nil || c.Get("a").String() != "xx" {
+		t.Errorf("Repeated references are not a cycle: got %q, %v", c.Get("a").String(), err)
+	}
+}
+
+func TestResolveThenExpandEnv(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"host": "db.local",
+		"url":  "${PROTO:-postgres}://${host}:${PORT:-${DEFAULT_PORT}}",
+	})
+	if err := c.Resolve(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Expand(VarsFromMap(map[string]string{"DEFAULT_PORT": "5432"}), true); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Get("url").String(); got != "postgres://db.local:5432" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestSecretsWithDollarSurviveAllPasses(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"x":    "ref",
+		"pass": "${secret:db}",
+		"dsn":  "user:${secret:db}@${x}",
+	}).WithSecretResolver(SecretMap{"db": "pa$$w0rd${x}$"})
+	if err := c.ResolveSecrets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Resolve(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Expand(VarsFromMap(map[string]string{"x": "env", "w0rd": "env"}), true); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"pass": "pa$$w0rd${x}$", "dsn": "user:pa$$w0rd${x}$@ref"}
+	for k, w := range want {
+		if got := c.Get(k).String(); got != w {
+			t.Errorf("%s: got %q, want %q", k, got, w)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package conf8n
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Validates config against JSON Schema (draft-07). Supported keywords: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// minLength, maxLength, pattern, format (see SchemaKey.Format() for known formats; unknown ones are ignored),
+// allOf, anyOf, oneOf and not ($ref is not supported). Numbers are compared regardless
+// of their representation, so configs, loaded from YAML and JSON, are validated the same way.
+// Returns all found problems, sorted by paths (nil, if config is valid)
+func (c *Config) ValidateJSONSchema(schema []byte) ValidationErrors {
+	var s interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return ValidationErrors{{Err: fmt.Errorf("Invalid JSON schema: %v", err)}}
+	}
+	v := &schemaValidator{c: c, sep: c.separator()}
+	v.validate(c.tree(), s, "")
+	v.errors.result()
+	return v.errors
+}
+
+type schemaValidator struct {
+	c      *Config
+	sep    string
+	errors ValidationErrors
+}
+
+func (v *schemaValidator) fail(path, keyword, format string, args ...interface{}) {
+	v.errors = append(v.errors, v.c.validationError(path, keyword, fmt.Errorf(format, args...)))
+}
+
+// Returns true if value is valid against schema (used by anyOf, oneOf and not)
+func (v *schemaValidator) valid(value, schema interface{}, path string) bool {
+	sub := &schemaValidator{c: v.c, sep: v.sep}
+	sub.validate(value, schema, path)
+	return len(sub.errors) == 0
+}
+
+func (v *schemaValidator) validate(value, schema interface{}, path string) {
+	if b, ok := schema.(bool); ok {
+		if !b {
+			v.fail(path, "false", "No value is allowed")
+		}
+		return
+	}
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if t, found := s["type"]; found && !jsonTypeMatches(value, t) {
+		v.fail(path, "type", "Value is a %s, expected %v", jsonTypeOf(value), t)
+		return
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		matched := false
+		for _, e := range enum {
+			matched = matched || jsonEqual(value, e)
+		}
+		if !matched {
+			v.fail(path, "enum", "Value %s is not one of %v", showValue(path, value), enum)
+		}
+	}
+	if c, found := s["const"]; found && !jsonEqual(value, c) {
+		v.fail(path, "const", "Value %s is not equal to %v", showValue(path, value), c)
+	}
+	if m := toStrMap(value); m != nil {
+		v.validateObject(m, s, path)
+	}
+	if a, ok := value.([]interface{}); ok {
+		v.validateArray(a, s, path)
+	}
+	if n, ok := toNumber(value); ok {
+		v.validateNumber(n, s, path)
+	}
+	if str, ok := value.(string); ok {
+		v.validateString(str, s, path)
+	}
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(value, sub, path)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			matched = matched || v.valid(value, sub, path)
+		}
+		if !matched {
+			v.fail(path, "anyOf", "Value doesn't match any of schemas")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.valid(value, sub, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "oneOf", "Value matches %d schemas, expected exactly one", count)
+		}
+	}
+	if not, found := s["not"]; found && v.valid(value, not, path) {
+		v.fail(path, "not", "Value must not match schema")
+	}
+}
+
+func (v *schemaValidator) validateObject(m map[string]interface{}, s map[string]interface{}, path string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, found := m[name]; !found {
+				v.fail(joinKey(path, name, v.sep), "required", "Value is required, but not set")
+			}
+		}
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	for _, k := range mapSortedKeys(m) {
+		if propSchema, found := props[k]; found {
+			v.validate(m[k], propSchema, joinKey(path, k, v.sep))
+		} else if additional, found := s["additionalProperties"]; found {
+			if b, ok := additional.(bool); ok && !b {
+				v.fail(joinKey(path, k, v.sep), "additionalProperties", "Key is not allowed")
+			} else {
+				v.validate(m[k], additional, joinKey(path, k, v.sep))
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(a []interface{}, s map[string]interface{}, path string) {
+	if n, ok := toNumber(s["minItems"]); ok && float64(len(a)) < n {
+		v.fail(path, "minItems", "Slice has %d elements, expected at least %v", len(a), n)
+	}
+	if n, ok := toNumber(s["maxItems"]); ok && float64(len(a)) > n {
+		v.fail(path, "maxItems", "Slice has %d elements, expected at most %v", len(a), n)
+	}
+	switch items := s["items"].(type) {
+	case []interface{}:
+		for i := 0; i < len(a) && i < len(items); i++ {
+			v.validate(a[i], items[i], joinKey(path, fmt.Sprint(i), v.sep))
+		}
+	case nil:
+	default:
+		for i, el := range a {
+			v.validate(el, items, joinKey(path, fmt.Sprint(i), v.sep))
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(n float64, s map[string]interface{}, path string) {
+	if min, ok := toNumber(s["minimum"]); ok && n < min {
+		v.fail(path, "minimum", "Value %s is less than %v", showValue(path, n), min)
+	}
+	if max, ok := toNumber(s["maximum"]); ok && n > max {
+		v.fail(path, "maximum", "Value %s is greater than %v", showValue(path, n), max)
+	}
+	if min, ok := toNumber(s["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "exclusiveMinimum", "Value %s is not greater than %v", showValue(path, n), min)
+	}
+	if max, ok := toNumber(s["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "exclusiveMaximum", "Value %s is not less than %v", showValue(path, n), max)
+	}
+	if d, ok := toNumber(s["multipleOf"]); ok && d != 0 && math.Abs(math.Remainder(n, d)) > 1e-9 {
+		v.fail(path, "multipleOf", "Value %s is not a multiple of %v", showValue(path, n), d)
+	}
+}
+
+func (v *schemaValidator) validateString(str string, s map[string]interface{}, path string) {
+	length := float64(utf8.RuneCountInString(str))
+	if n, ok := toNumber(s["minLength"]); ok && length < n {
+		v.fail(path, "minLength", "Value %s is shorter than %v characters", showValue(path, str), n)
+	}
+	if n, ok := toNumber(s["maxLength"]); ok && length > n {
+		v.fail(path, "maxLength", "Value %s is longer than %v characters", showValue(path, str), n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "pattern", "Invalid pattern '%s': %v", pattern, err)
+		} else if !re.MatchString(str) {
+			v.fail(path, "pattern", "Value %s doesn't match pattern '%s'", showValue(path, str), pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok {
+		if valid, found := formats[format]; found && !valid(str) {
+			v.fail(path, "format", "Value %s is not a valid %s", showValue(path, str), format)
+		}
+	}
+}
+
+// Returns JSON Schema type name of value
+func jsonTypeOf(value interface{}) string {
+	switch kindOf(value) {
+	case Nil:
+		return "null"
+	case Bool:
+		return "boolean"
+	case Int:
+		return "integer"
+	case Float:
+		if n, _ := toNumber(value); n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case String:
+		return "string"
+	case Slice:
+		return "array"
+	case Map:
+		return "object"
+	}
+	return kindOf(value).String()
+}
+
+// Checks value against "type" keyword (string or list of strings); integers are numbers too
+func jsonTypeMatches(value interface{}, t interface{}) bool {
+	types, ok := t.([]interface{})
+	if !ok {
+		types = []interface{}{t}
+	}
+	actual := jsonTypeOf(value)
+	for _, expected := range types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// Compares values deeply; numbers are compared regardless of their representation
+func jsonEqual(a, b interface{}) bool {
+	if ma, mb := toStrMap(a), toStrMap(b); ma != nil || mb != nil {
+		if ma == nil || mb == nil || len(ma) != len(mb) {
+			return false
+		}
+		for k, va := range ma {
+			if vb, found := mb[k]; !found || !jsonEqual(va, vb) {
+				return false
+			}
+		}
+		return true
+	}
+	aa, aok := a.([]interface{})
+	ab, bok := b.([]interface{})
+	if aok || bok {
+		if !aok || !bok || len(aa) != len(ab) {
+			return false
+		}
+		for i := range aa {
+			if !jsonEqual(aa[i], ab[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return looseEqual(a, b)
+}
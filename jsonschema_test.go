@@ -0,0 +1,95 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		tree   map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "type",
+			schema: `{"properties": {"a": {"type": "integer"}, "b": {"type": "number"}, "c": {"type": "integer"}, "d": {"type": ["string", "null"]}, "e": {"type": "object"}}}`,
+			tree:   map[string]interface{}{"a": 1.0, "b": 1, "c": 1.5, "d": nil, "e": []interface{}{}},
+			want:   []string{"c type", "e type"},
+		},
+		{
+			name:   "required",
+			schema: `{"required": ["a", "b"], "properties": {"s": {"required": ["c"]}}}`,
+			tree:   map[string]interface{}{"a": nil, "s": map[string]interface{}{}},
+			want:   []string{"b required", "s.c required"},
+		},
+		{
+			name:   "enum and const",
+			schema: `{"properties": {"a": {"enum": ["x", 1]}, "b": {"enum": ["x", 1]}, "c": {"const": [1, {"k": "v"}]}, "d": {"const": 2}}}`,
+			tree: map[string]interface{}{"a": 1.0, "b": "y",
+				"c": []interface{}{1, map[string]interface{}{"k": "v"}}, "d": 3},
+			want: []string{"b enum", "d const"},
+		},
+		{
+			name:   "numbers",
+			schema: `{"properties": {"a": {"minimum": 1, "maximum": 10}, "b": {"minimum": 1}, "c": {"maximum": 10}, "d": {"exclusiveMinimum": 1}, "e": {"exclusiveMaximum": 10}, "f": {"multipleOf": 0.5}, "g": {"multipleOf": 0.5}}}`,
+			tree:   map[string]interface{}{"a": 10, "b": 0.5, "c": 11, "d": 1, "e": 10.0, "f": 2.5, "g": 2.25},
+			want:   []string{"b minimum", "c maximum", "d exclusiveMinimum", "e exclusiveMaximum", "g multipleOf"},
+		},
+		{
+			name:   "strings",
+			schema: `{"properties": {"a": {"minLength": 2, "maxLength": 3}, "b": {"minLength": 2}, "c": {"maxLength": 3}, "d": {"pattern": "^[a-z]+$"}, "e": {"format": "ipv4"}, "f": {"format": "unknown"}}}`,
+			tree:   map[string]interface{}{"a": "абв", "b": "x", "c": "abcd", "d": "a1", "e": "1.2.3", "f": "x"},
+			want:   []string{"b minLength", "c maxLength", "d pattern", "e format"},
+		},
+		{
+			name:   "arrays",
+			schema: `{"properties": {"a": {"minItems": 2, "maxItems": 3, "items": {"type": "string"}}, "b": {"minItems": 2}, "c": {"maxItems": 1}, "d": {"items": [{"type": "string"}, {"type": "integer"}]}}}`,
+			tree: map[string]interface{}{
+				"a": []interface{}{"x", 1},
+				"b": []interface{}{1},
+				"c": []interface{}{1, 2},
+				"d": []interface{}{"x", "y", "z"},
+			},
+			want: []string{"a.1 type", "b minItems", "c maxItems", "d.1 type"},
+		},
+		{
+			name:   "additional properties",
+			schema: `{"properties": {"a": {}, "s": {"additionalProperties": {"type": "integer"}}}, "additionalProperties": false}`,
+			tree:   map[string]interface{}{"a": 1, "b": 2, "s": map[string]interface{}{"x": 1, "y": "2"}},
+			want:   []string{"b additionalProperties", "s.y type"},
+		},
+		{
+			name:   "combinators",
+			schema: `{"properties": {"a": {"allOf": [{"minimum": 1}, {"maximum": 5}]}, "b": {"anyOf": [{"type": "string"}, {"type": "boolean"}]}, "c": {"oneOf": [{"type": "number"}, {"type": "integer"}]}, "d": {"oneOf": [{"type": "string"}, {"type": "integer"}]}, "e": {"not": {"type": "null"}}}}`,
+			tree:   map[string]interface{}{"a": 6, "b": 1, "c": 1, "d": 1, "e": nil},
+			want:   []string{"a maximum", "b anyOf", "c oneOf", "e not"},
+		},
+		{
+			name:   "boolean schemas",
+			schema: `{"properties": {"a": true, "b": false}}`,
+			tree:   map[string]interface{}{"a": 1, "b": 2},
+			want:   []string{"b false"},
+		},
+		{
+			name:   "valid",
+			schema: `{"type": "object", "required": ["server"], "properties": {"server": {"type": "object", "properties": {"port": {"type": "integer", "minimum": 1}}}}}`,
+			tree:   map[string]interface{}{"server": map[string]interface{}{"port": 8080.0}},
+			want:   nil,
+		},
+	}
+	for _, test := range tests {
+		got := problemList(NewConfig(test.tree).ValidateJSONSchema([]byte(test.schema)))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateJSONSchemaInvalid(t *testing.T) {
+	problems := NewConfig(map[string]interface{}{}).ValidateJSONSchema([]byte(`{"type": `))
+	if len(problems) != 1 || problems[0].Path != "" {
+		t.Errorf("Single problem of invalid schema expected, got %v", problems)
+	}
+}
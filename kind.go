@@ -0,0 +1,74 @@
+package conf8n
+
+import (
+	"time"
+)
+
+// Kind of value, stored in config. See ConfigValue.Kind()
+type ValueKind int
+
+const (
+	Nil ValueKind = iota
+	Bool
+	Int
+	Float
+	String
+	Slice
+	Map
+	Time
+	Bytes
+	Other
+)
+
+var kindNames = [...]string{
+	Nil:    "nil",
+	Bool:   "bool",
+	Int:    "int",
+	Float:  "float",
+	String: "string",
+	Slice:  "slice",
+	Map:    "map",
+	Time:   "time",
+	Bytes:  "bytes",
+	Other:  "other",
+}
+
+// Returns human-readable name of the kind (used in error messages)
+func (k ValueKind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[Other]
+	}
+	return kindNames[k]
+}
+
+// Returns kind of underlying value.
+// Kind is defined by the type decoder gave us, without any conversions: keep in mind, that JSON decoder
+// represents all numbers as float64, so number 5 loaded from JSON will be reported as Float,
+// while the same number loaded from YAML will be reported as Int
+func (v ConfigValue) Kind() ValueKind {
+	return kindOf(v.v)
+}
+
+func kindOf(value interface{}) ValueKind {
+	switch value.(type) {
+	case nil:
+		return Nil
+	case bool:
+		return Bool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return Int
+	case float32, float64:
+		return Float
+	case string:
+		return String
+	case []interface{}:
+		return Slice
+	case map[string]interface{}, map[interface{}]interface{}:
+		return Map
+	case time.Time:
+		return Time
+	case []byte:
+		return Bytes
+	}
+	return Other
+}
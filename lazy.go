@@ -0,0 +1,41 @@
+package conf8n
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Section with keys of other types than strings, converted to string-keyed map on first need
+type lazySection struct {
+	raw  map[interface{}]interface{}
+	once sync.Once
+	done uint32
+}
+
+// Returns sub-config, viewing given section without its conversion. Lookups (Get(), Has() and others) search
+// the section as is; string-keyed copy of it is made, when config data is needed as a whole (for iteration,
+// serialization, changes, etc). As with eager conversion, changes of such sub-config are not seen by its parent
+func (c *Config) lazySub(raw map[interface{}]interface{}, chunks []string) *Config {
+	sub := c.sub(nil, chunks)
+	sub.lazy = &lazySection{raw: raw}
+	return sub
+}
+
+// Returns config data for lookups: not converted section of lazy sub-config or string-keyed map
+func (c *Config) root() interface{} {
+	if l := c.lazy; l != nil && atomic.LoadUint32(&l.done) == 0 {
+		return l.raw
+	}
+	return c.data
+}
+
+// Returns config data as string-keyed map, converting section of lazy sub-config (once)
+func (c *Config) tree() map[string]interface{} {
+	if l := c.lazy; l != nil && atomic.LoadUint32(&l.done) == 0 {
+		l.once.Do(func() {
+			c.data = toStrMap(l.raw)
+			atomic.StoreUint32(&l.done, 1)
+		})
+	}
+	return c.data
+}
@@ -0,0 +1,60 @@
+package conf8n
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Returns section with keys of interface type (as yaml.v2 decodes them) of given size
+func rawSection(size int) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, size)
+	for i := 0; i < size; i++ {
+		m[fmt.Sprint("r", i)] = map[interface{}]interface{}{"timeout": i}
+	}
+	return m
+}
+
+func TestLazySubConfig(t *testing.T) {
+	routes := rawSection(10)
+	routes[80], routes[1.5], routes[true], routes[-2] = "int", "float", "bool", "negative"
+	parent := NewConfig(map[string]interface{}{"routes": routes})
+	sub := parent.Get("routes").Config()
+	for key, want := range map[string]string{"80": "int", "1.5": "float", "true": "bool", "-2": "negative", "r1": ""} {
+		if got := sub.Get(key).String(); got != want {
+			t.Errorf("Key '%s': got %q, want %q", key, got, want)
+		}
+	}
+	if got := sub.Get("r5.timeout").Int(); got != 5 {
+		t.Errorf("Lookup: got %d", got)
+	}
+	if err := sub.Set("r5.timeout", 50); err != nil {
+		t.Fatal(err)
+	}
+	if got := sub.Get("r5.timeout").Int(); got != 50 {
+		t.Errorf("Lookup after Set(): got %d", got)
+	}
+	if keys := sub.Keys(); len(keys) != 14 {
+		t.Errorf("Got keys %v", keys)
+	}
+	if got := parent.Get("routes.r5.timeout").Int(); got != 5 {
+		t.Errorf("Change of sub-config is not expected to be seen by parent, got %d", got)
+	}
+}
+
+// Takes single key from sub-config of huge section: lazy (as Config() does) and with eager conversion of section
+func BenchmarkOneKeyOfHugeSection(b *testing.B) {
+	c := NewConfig(map[string]interface{}{"routes": rawSection(50000)})
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Get("routes").Config().Get("r100.timeout")
+		}
+	})
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v := c.Get("routes")
+			v.c.sub(toStrMap(v.v), v.chunks()).Get("r100.timeout")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package conf8n
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Holder of current config, which can be replaced at any time (for example, on reloading of config file).
+// Readers get config with Load() and never see partially applied update; reading doesn't take locks
+type Live struct {
+	current  atomic.Value
+	mu       sync.Mutex
+	validate func(c *Config) error
+	onError  func(err error)
+	subs     []*Subscription
+	statusMu sync.RWMutex
+	lastErr  error
+	lastGood time.Time
+	history  reloadHistory
+}
+
+// Creates holder with given config
+func NewLive(c *Config) *Live {
+	l := &Live{onError: func(err error) {
+		log.Printf("conf8n: config is not updated: %v", err)
+	}}
+	l.history.size = DefaultHistorySize
+	l.current.Store(c)
+	if c != nil {
+		l.lastGood = time.Now()
+	}
+	return l
+}
+
+// Loads config from file (see NewConfigFromFile()) and returns holder, that is updated on every change of the
+// file (see WatchFile()) until context is canceled. Reloaded config is checked by validator (see WithValidator())
+// before it is stored; if reloading or validation fails, error is reported (see OnError()) and the previous
+// config is kept
+func NewLiveFromFile(ctx context.Context, filename string, opts ...LoadOption) (*Live, error) {
+	return newWatchedLive(filename, func(onChange func(c *Config, err error)) (*Watcher, error) {
+		return WatchFile(ctx, filename, onChange, opts...)
+	})
+}
+
+// Same as NewLiveFromFile(), but config is loaded from URL and polled with given interval (see WatchURL())
+func NewLiveFromURL(ctx context.Context, url string, interval time.Duration, opts ...LoadOption) (*Live, error) {
+	return newWatchedLive(url, func(onChange func(c *Config, err error)) (*Watcher, error) {
+		return WatchURL(ctx, url, interval, onChange, opts...)
+	})
+}
+
+// Creates holder, updated by watcher of given source, started by given function
+func newWatchedLive(source string, watch func(onChange func(c *Config, err error)) (*Watcher, error)) (*Live, error) {
+	l := NewLive(nil)
+	w, err := watch(func(c *Config, err error) {
+		l.reload(source, c, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// config may be already updated by the watcher
+	if l.Load() == nil {
+		l.current.Store(w.Config())
+		l.record(source, nil, w.Config(), nil)
+	}
+	return l, nil
+}
+
+// Sets function, checking candidate configs before they are stored by Update(), and returns holder itself
+func (l *Live) WithValidator(fn func(c *Config) error) *Live {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.validate = fn
+	return l
+}
+
+// Sets callback for errors of automatic updates (by default they are written to standard logger)
+// and returns holder itself
+func (l *Live) OnError(fn func(err error)) *Live {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onError = fn
+	return l
+}
+
+// Returns current config
+func (l *Live) Load() *Config {
+	c, _ := l.current.Load().(*Config)
+	return c
+}
+
+// Replaces current config without validation
+func (l *Live) Store(c *Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.record("", l.swap(c), c, nil)
+}
+
+// Checks config by validator (see WithValidator()) and, if it is valid, makes it current.
+// Otherwise error of validator is returned and remembered as the last error (see LastError())
+func (l *Live) Update(c *Config) error {
+	return l.update("", c)
+}
+
+func (l *Live) update(source string, c *Config) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.validate != nil {
+		if err := l.validate(c); err != nil {
+			l.record(source, l.Load(), c, err)
+			return err
+		}
+	}
+	l.record(source, l.swap(c), c, nil)
+	return nil
+}
+
+// Handles result of automatic reloading from given source: updates holder or reports error
+func (l *Live) reload(source string, c *Config, err error) {
+	if err == nil {
+		err = l.update(source, c)
+	} else {
+		l.mu.Lock()
+		l.record(source, l.Load(), nil, err)
+		l.mu.Unlock()
+	}
+	if err != nil {
+		l.mu.Lock()
+		onError := l.onError
+		l.mu.Unlock()
+		onError(err)
+	}
+}
+
+// Returns error of the last update attempt (failed loading, reported by watcher, or rejection by validator),
+// or nil, if the last attempt succeeded. Non-nil error means, that holder keeps outdated config
+func (l *Live) LastError() error {
+	l.statusMu.RLock()
+	defer l.statusMu.RUnlock()
+	return l.lastErr
+}
+
+// Returns time of the last successful update of config (zero time, if there were no configs)
+func (l *Live) LastGoodLoadTime() time.Time {
+	l.statusMu.RLock()
+	defer l.statusMu.RUnlock()
+	return l.lastGood
+}
+
+// Records result of update attempt: previous config, candidate config (nil, if it failed to load) and error.
+// Must be called with locked mutex
+func (l *Live) record(source string, prev, next *Config, err error) {
+	now := time.Now()
+	l.statusMu.Lock()
+	l.lastErr = err
+	if err == nil {
+		l.lastGood = now
+	}
+	l.statusMu.Unlock()
+	l.history.add(now, source, prev, next, err)
+}
+
+// Makes config current and notifies subscribers. Returns previous config. Must be called with locked mutex
+func (l *Live) swap(c *Config) *Config {
+	old := l.Load()
+	l.current.Store(c)
+	for _, s := range l.subs {
+		s.enqueue(old, c)
+	}
+	return old
+}
@@ -0,0 +1,195 @@
+package conf8n
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLive(t *testing.T) {
+	first := NewConfig(map[string]interface{}{"v": 1})
+	l := NewLive(first)
+	if l.Load() != first || l.LastError() != nil || l.LastGoodLoadTime().IsZero() {
+		t.Fatalf("Initial state: got %v, %v, %v", l.Load(), l.LastError(), l.LastGoodLoadTime())
+	}
+	if NewLive(nil).Load() != nil || !NewLive(nil).LastGoodLoadTime().IsZero() {
+		t.Errorf("Holder without config is expected to give nil config and zero time")
+	}
+	second := NewConfig(map[string]interface{}{"v": 2})
+	l.Store(second)
+	if l.Load() != second {
+		t.Errorf("Stored config is expected to be current")
+	}
+}
+
+func TestLiveValidation(t *testing.T) {
+	invalid := errors.New("v must be positive")
+	first := NewConfig(map[string]interface{}{"v": 1})
+	l := NewLive(first).WithValidator(func(c *Config) error {
+		if c.Get("v").Int() <= 0 {
+			return invalid
+		}
+		return nil
+	})
+	steps := []struct {
+		v       int
+		err     error
+		current int
+	}{
+		{2, nil, 2},
+		{0, invalid, 2},
+		{3, nil, 3},
+	}
+	for _, s := range steps {
+		err := l.Update(NewConfig(map[string]interface{}{"v": s.v}))
+		if !errors.Is(err, s.err) || !errors.Is(l.LastError(), s.err) {
+			t.Errorf("v=%d: got errors %v and %v, want %v", s.v, err, l.LastError(), s.err)
+		}
+		if got := l.Load().Get("v").Int(); got != s.current {
+			t.Errorf("v=%d: current config has v=%d, want %d", s.v, got, s.current)
+		}
+	}
+	// Store() doesn't validate
+	l.Store(NewConfig(map[string]interface{}{"v": -1}))
+	if got := l.Load().Get("v").Int(); got != -1 {
+		t.Errorf("Got %d", got)
+	}
+}
+
+func TestLiveReloadErrors(t *testing.T) {
+	var reported []error
+	first := NewConfig(map[string]interface{}{"v": 1})
+	l := NewLive(first).
+		WithValidator(func(c *Config) error {
+			if !c.Has("v") {
+				return errors.New("v is not set")
+			}
+			return nil
+		}).
+		OnError(func(err error) { reported = append(reported, err) })
+	loadErr := errors.New("parse error")
+	l.reload("config.yaml", nil, loadErr)
+	l.reload("config.yaml", NewConfig(map[string]interface{}{}), nil)
+	if len(reported) != 2 || !errors.Is(reported[0], loadErr) {
+		t.Errorf("Errors of loading and validation are expected to be reported, got %v", reported)
+	}
+	if l.Load() != first || l.LastError() == nil {
+		t.Errorf("Last known good config is expected to be kept, got %v, %v", l.Load(), l.LastError())
+	}
+	l.reload("config.yaml", NewConfig(map[string]interface{}{"v": 2}), nil)
+	if l.Load().Get("v").Int() != 2 || l.LastError() != nil || len(reported) != 2 {
+		t.Errorf("Got %v, %v, %v", l.Load(), l.LastError(), reported)
+	}
+}
+
+// Run with -race
+func TestLiveConcurrentAccess(t *testing.T) {
+	l := NewLive(NewConfig(map[string]interface{}{"v": 0}))
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Store(NewConfig(map[string]interface{}{"v": g*100 + i}))
+				_ = l.Update(NewConfig(map[string]interface{}{"v": i}))
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if c := l.Load(); c == nil || !c.Has("v") {
+					t.Error("Holder gave incomplete config")
+					return
+				}
+				l.LastError()
+				l.LastGoodLoadTime()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewLiveFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf8n-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, filename, "v: 1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := NewLiveFromFile(ctx, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Load().Get("v").Int(); got != 1 {
+		t.Errorf("Got %d", got)
+	}
+	if h := l.History(); len(h) != 1 || h[0].Source != filename {
+		t.Errorf("Initial load is expected to be recorded, got %v", h)
+	}
+	if _, err := NewLiveFromFile(ctx, filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("Missing file is expected to be reported")
+	}
+}
+
+func TestLiveKeepsLastKnownGoodConfig(t *testing.T) {
+	defer withWatchDebounce(10 * time.Millisecond)()
+	dir, err := ioutil.TempDir("", "conf8n-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, filename, "port: 80\n")
+	n := newStubNotifier()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := newWatchedLive(filename, func(onChange func(c *Config, err error)) (*Watcher, error) {
+		return WatchFileWith(ctx, n, filename, onChange)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 10)
+	l.OnError(func(err error) { errs <- err }).WithValidator(func(c *Config) error {
+		if c.Get("port").Int() <= 0 {
+			return errors.New("Port must be positive")
+		}
+		return nil
+	})
+	good := l.Load()
+	goodTime := l.LastGoodLoadTime()
+	for _, content := range []string{"port: 0\n", "port: [\n"} {
+		writeTestFile(t, filename, content)
+		n.events <- filename
+		select {
+		case err := <-errs:
+			if !errors.Is(l.LastError(), err) {
+				t.Errorf("%q: reported error %v is expected to be the last one, got %v", content, err, l.LastError())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: error was not reported", content)
+		}
+		if l.Load() != good || !l.LastGoodLoadTime().Equal(goodTime) {
+			t.Errorf("%q: last known good config is expected to be kept", content)
+		}
+	}
+	writeTestFile(t, filename, "port: 8080\n")
+	n.events <- filename
+	for deadline := time.Now().Add(5 * time.Second); l.Load().Get("port").Int() != 8080; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Valid config was not applied")
+		}
+	}
+	if l.LastError() != nil || !l.LastGoodLoadTime().After(goodTime) {
+		t.Errorf("Got last error %v, last good time %v (was %v)", l.LastError(), l.LastGoodLoadTime(), goodTime)
+	}
+}
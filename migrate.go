@@ -0,0 +1,144 @@
+package conf8n
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Ordered steps, upgrading config from older versions of its format. Example:
+//
+//	m := conf8n.NewMigrations()
+//	m.Add(0, func(c *conf8n.Config) error { // version 0 (no version key) -> 1: "host" moved to "server.host"
+//		err := c.Set("server.host", c.Get("host").Raw())
+//		c.Delete("host")
+//		return err
+//	})
+//	version, err := m.Migrate(config, "version")
+type Migrations struct {
+	steps map[int]func(c *Config) error
+}
+
+// Creates empty set of migrations
+func NewMigrations() *Migrations {
+	return &Migrations{steps: make(map[int]func(c *Config) error)}
+}
+
+// Registers step, upgrading config from given version to the next one. Panics if step for the version
+// is already registered. Returns migrations themselves
+func (m *Migrations) Add(fromVersion int, step func(c *Config) error) *Migrations {
+	if _, found := m.steps[fromVersion]; found {
+		panic(fmt.Sprintf("conf8n: migration from version %d is already registered", fromVersion))
+	}
+	m.steps[fromVersion] = step
+	return m
+}
+
+// Returns version, config gets after all registered steps (0 if there are no steps)
+func (m *Migrations) Latest() int {
+	latest := 0
+	for from := range m.steps {
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+	return latest
+}
+
+// Reads current version of config by given key (missing key means version 0), applies needed steps in order,
+// setting the version key after every step, and returns final version. Steps are applied to a copy of config,
+// so if some step fails, config is left untouched (version, reached before failure, is returned with the error)
+func (m *Migrations) Migrate(c *Config, versionKey string) (int, error) {
+	migrated, version, err := m.migrate(c, versionKey)
+	if err != nil {
+		return version, err
+	}
+	c.detach()
+	c.data, c.lazy = migrated.tree(), nil
+	c.invalidate()
+	return version, nil
+}
+
+// Same as Migrate(), but doesn't change config. Returns version, config would get, and changes, that would be made,
+// as sorted list of lines: "+ key: value" (added), "- key: value" (removed) and "~ key: old -> new" (changed)
+func (m *Migrations) DryRun(c *Config, versionKey string) (int, []string, error) {
+	migrated, version, err := m.migrate(c, versionKey)
+	if err != nil {
+		return version, nil, err
+	}
+	var diff []string
+	diffTrees(c.tree(), migrated.tree(), "", c.separator(), &diff)
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+	return version, diff, nil
+}
+
+func (m *Migrations) migrate(c *Config, versionKey string) (*Config, int, error) {
+	version, err := c.version(versionKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := deepCopy(c.tree())
+	if err != nil {
+		return nil, version, err
+	}
+	work := c.withData(data.(map[string]interface{}))
+	for latest := m.Latest(); version < latest; version++ {
+		step, found := m.steps[version]
+		if !found {
+			return nil, version, fmt.Errorf("No migration from version %d", version)
+		}
+		if err := step(work); err != nil {
+			return nil, version, fmt.Errorf("Migration from version %d failed: %w", version, err)
+		}
+		if err := work.Set(versionKey, version+1); err != nil {
+			return nil, version, err
+		}
+	}
+	return work, version, nil
+}
+
+// Returns version of config format, stored by given key (0 if key is not set)
+func (c *Config) version(key string) (int, error) {
+	v := c.Get(key)
+	if !v.IsSet() {
+		return 0, nil
+	}
+	n, isNumber := toNumber(v.v)
+	if !isNumber || n < 0 || n != math.Trunc(n) {
+		return 0, c.keyError(key, fmt.Errorf("Version must be a non-negative integer, got %s", showValue(key, v.v)))
+	}
+	return int(n), nil
+}
+
+// Returns new config with other data and the same settings of keys (separator, case folding and precedence).
+// State of c (overrides, tracking of deprecated and accessed keys, etc) is not shared with it
+func (c *Config) withData(data map[string]interface{}) *Config {
+	cp := NewConfig(data)
+	cp.sep, cp.foldCase, cp.prec = c.sep, c.foldCase, c.prec
+	return cp
+}
+
+// Collects differences between leaves of trees a and b
+func diffTrees(a, b interface{}, path, sep string, diff *[]string) {
+	ma, mb := toStrMap(a), toStrMap(b)
+	if ma != nil && mb != nil {
+		for k, va := range ma {
+			if vb, found := mb[k]; found {
+				diffTrees(va, vb, joinKey(path, k, sep), sep, diff)
+			} else {
+				*diff = append(*diff, fmt.Sprintf("- %s: %s", joinKey(path, k, sep), yamlScalar(va)))
+			}
+		}
+		for k, vb := range mb {
+			if _, found := ma[k]; !found {
+				*diff = append(*diff, fmt.Sprintf("+ %s: %s", joinKey(path, k, sep), yamlScalar(vb)))
+			}
+		}
+		return
+	}
+	if !jsonEqual(a, b) {
+		*diff = append(*diff, fmt.Sprintf("~ %s: %s -> %s", path, yamlScalar(a), yamlScalar(b)))
+	}
+}
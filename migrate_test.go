@@ -0,0 +1,29 @@
+package conf8n
+
+import (
+	"testing"
+)
+
+func TestMigrationDoesNotShareState(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"db": map[string]interface{}{"host": "db.local"}}).WithSeparator("/").TrackAccess()
+	scope := c.PushOverrides(map[string]interface{}{"db/host": "override.local"})
+	defer scope.Close()
+	m := NewMigrations().Add(0, func(work *Config) error {
+		return work.Set("db/addr", work.Get("db/host").String())
+	})
+	version, err := m.Migrate(c, "version")
+	if err != nil || version != 1 {
+		t.Fatalf("Got version %d, error %v", version, err)
+	}
+	if got := c.Get("db/addr").String(); got != "db.local" {
+		t.Errorf("Migration is expected to see data of config, not overrides: got %q", got)
+	}
+	for _, key := range c.AccessedKeys() {
+		if key == "db/host" || key == "db.host" {
+			t.Errorf("Access of migration step is recorded for config: %v", c.AccessedKeys())
+		}
+	}
+	if got := c.Get("db/host").String(); got != "override.local" {
+		t.Errorf("Overrides of config are expected to be kept: got %q", got)
+	}
+}
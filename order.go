@@ -0,0 +1,145 @@
+package conf8n
+
+import (
+	"bytes"
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strconv"
+)
+
+// Same as Iterate(), but map keys are iterated in the order they appear in source document
+// (for configs, loaded from YAML or JSON data, and their sub-configs). Keys with unknown order
+// (for example, added with Set()) follow them in sorted order
+func (v ConfigValue) IterateOrdered() Iterator {
+	m := toStrMap(v.v)
+	if m == nil {
+		return v.Iterate()
+	}
+	keys := v.orderedKeys(m)
+	a := make([]interface{}, len(keys))
+	for i, k := range keys {
+		a[i] = k
+	}
+	return &MapIterator{&ListIterator{a: a, c: v.c, key: v.key, keyed: v.key != ""}, m}
+}
+
+// Returns keys of map value in source order (see IterateOrdered())
+func (v ConfigValue) orderedKeys(m map[string]interface{}) []string {
+	var known []string
+	if v.c != nil && v.c.order != nil && (v.key != "" || sameMap(m, v.c.tree())) {
+		path := append(append([]string{}, v.c.path...), v.chunks()...)
+		known = v.c.order[joinKeyChunks(path, SEP)]
+	}
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, k := range known {
+		if _, found := m[k]; found && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	for _, k := range mapSortedKeys(m) {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func sameMap(a, b map[string]interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// Collects keys order of all mappings of YAML document. Keys, merged with "<<", go before own keys of mapping
+func yamlKeyOrder(node *yaml.Node, path []string, order map[string][]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlKeyOrder(n, path, order)
+		}
+	case yaml.MappingNode:
+		pathKey := joinKeyChunks(path, SEP)
+		order[pathKey] = append(order[pathKey], yamlMappingKeys(node)...)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag == "!!merge" {
+				continue
+			}
+			yamlKeyOrder(value, append(append([]string{}, path...), key.Value), order)
+		}
+	case yaml.SequenceNode:
+		for i, el := range node.Content {
+			yamlKeyOrder(el, append(append([]string{}, path...), strconv.Itoa(i)), order)
+		}
+	}
+}
+
+func yamlMappingKeys(node *yaml.Node) []string {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	var keys []string
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag == "!!merge" {
+				keys = append(keys, yamlMappingKeys(value)...)
+			} else {
+				keys = append(keys, key.Value)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, el := range node.Content {
+			keys = append(keys, yamlMappingKeys(el)...)
+		}
+	}
+	return keys
+}
+
+// Collects keys order of all objects of JSON document
+func jsonKeyOrder(data []byte) map[string][]string {
+	type frame struct {
+		path      string
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+	order := make(map[string][]string)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return order
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		var path string
+		switch {
+		case top == nil:
+		case top.object && top.expectKey:
+			top.key, _ = tok.(string)
+			top.expectKey = false
+			order[top.path] = append(order[top.path], top.key)
+			continue
+		case top.object:
+			path = joinKey(top.path, top.key, SEP)
+			top.expectKey = true
+		default:
+			path = joinKey(top.path, strconv.Itoa(top.index), SEP)
+			top.index++
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, &frame{path: path, object: delim == '{', expectKey: delim == '{'})
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIterateOrdered(t *testing.T) {
+	constructors := map[string]func(data []byte, opts ...LoadOption) (*Config, error){
+		YAML: NewConfigFromYaml,
+		JSON: NewConfigFromJson,
+	}
+	documents := map[string]string{
+		YAML: "zeta: 1\nalpha:\n  c: 1\n  a: 2\nmid: [1, 2]\n",
+		JSON: `{"zeta": 1, "alpha": {"c": 1, "a": 2}, "mid": [1, 2]}`,
+	}
+	for format, newConfig := range constructors {
+		c, err := newConfig([]byte(documents[format]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Set("alpha.b", 3); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			key       string
+			keys      []string
+			valueKeys []string
+		}{
+			{"alpha", []string{"c", "a", "b"}, []string{"alpha.c", "alpha.a", "alpha.b"}},
+			{"mid", []string{"", ""}, []string{"mid.0", "mid.1"}},
+		}
+		for _, tt := range tests {
+			var keys, valueKeys []string
+			for it := c.Get(tt.key).IterateOrdered(); !it.Finished(); it.Next() {
+				keys = append(keys, it.Key())
+				valueKeys = append(valueKeys, it.Value().Key())
+			}
+			if !reflect.DeepEqual(keys, tt.keys) || !reflect.DeepEqual(valueKeys, tt.valueKeys) {
+				t.Errorf("%s, '%s': got keys %v and keys of values %v, want %v and %v",
+					format, tt.key, keys, valueKeys, tt.keys, tt.valueKeys)
+			}
+		}
+		var keys []string
+		for it := (ConfigValue{v: c.tree(), c: c}).IterateOrdered(); !it.Finished(); it.Next() {
+			keys = append(keys, it.Key())
+		}
+		if want := []string{"zeta", "alpha", "mid"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("%s, root: got keys %v, want %v", format, keys, want)
+		}
+	}
+}
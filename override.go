@@ -0,0 +1,148 @@
+package conf8n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Stack of temporary overrides, shared by config and its sub-configs. Allocated with config, so pushing
+// doesn't race with lookups; size mirrors len(layers) to skip locking in lookups, while stack is empty
+type overrideStack struct {
+	mu     sync.RWMutex
+	layers []*OverrideScope
+	size   uint32
+}
+
+// Layer of temporary overrides, created by Config.PushOverrides()
+type OverrideScope struct {
+	stack     *overrideStack
+	overrides []override
+}
+
+type override struct {
+	chunks []string
+	value  interface{}
+}
+
+// Pushes layer of temporary overrides (keyed as for Set()) on top of config and returns its scope. While scope
+// is active, lookups (Get(), Has() and others, also through sub-configs of any section) see
+// overridden values; the topmost layer, setting the key (or some of its parents), wins. Config data is not
+// changed, so iteration over keys (Keys(), Walk() and similar) does not see overrides:
+//
+//	scope := config.PushOverrides(map[string]interface{}{"feature.x": true})
+//	defer scope.Close()
+//
+// Pushing and popping scopes is safe for concurrent use with lookups
+func (c *Config) PushOverrides(values map[string]interface{}) *OverrideScope {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	// parents go first, so nested keys of the same layer are applied over them
+	sort.Strings(keys)
+	scope := &OverrideScope{stack: c.overrides}
+	for _, k := range keys {
+		chunks := append(append([]string{}, c.path...), c.keyChunks(k)...)
+		scope.overrides = append(scope.overrides, override{chunks: chunks, value: values[k]})
+	}
+	c.overrides.mu.Lock()
+	defer c.overrides.mu.Unlock()
+	c.overrides.layers = append(c.overrides.layers, scope)
+	atomic.StoreUint32(&c.overrides.size, uint32(len(c.overrides.layers)))
+	return scope
+}
+
+// Removes layer of overrides, restoring previous view of config. Scopes must be popped in reverse order
+// of pushing: popping scope, that has other scopes pushed after it, is reported as error. Popping already
+// popped scope does nothing
+func (s *OverrideScope) Pop() error {
+	s.stack.mu.Lock()
+	defer s.stack.mu.Unlock()
+	layers := s.stack.layers
+	for i := len(layers) - 1; i >= 0; i-- {
+		if layers[i] != s {
+			continue
+		}
+		if i != len(layers)-1 {
+			return fmt.Errorf("Override scope can't be popped before %d scope(s), pushed after it", len(layers)-1-i)
+		}
+		layers[i] = nil
+		s.stack.layers = layers[:i]
+		atomic.StoreUint32(&s.stack.size, uint32(i))
+		return nil
+	}
+	return nil
+}
+
+// Same as Pop(). Implements io.Closer
+func (s *OverrideScope) Close() error {
+	return s.Pop()
+}
+
+// Applies active overrides to result of lookup of the key
+func (c *Config) applyOverrides(key string, v interface{}, err error) (interface{}, error) {
+	c.overrides.mu.RLock()
+	defer c.overrides.mu.RUnlock()
+	if len(c.overrides.layers) == 0 {
+		return v, err
+	}
+	chunks := append([]string{}, c.path...)
+	if key != "" {
+		chunks = append(chunks, c.keyChunks(key)...)
+	}
+	f := c.keyFormat()
+	for _, layer := range c.overrides.layers {
+		for _, o := range layer.overrides {
+			switch {
+			case hasKeyPrefix(chunks, o.chunks, f.foldCase):
+				v, err = getValueWithCompositeKey(o.value, chunks[len(o.chunks):], f)
+			case hasKeyPrefix(o.chunks, chunks, f.foldCase):
+				if err != nil {
+					v = nil
+				}
+				v, err = overlayValue(v, o.chunks[len(chunks):], o.value, f), nil
+			}
+		}
+	}
+	return v, err
+}
+
+// Checks, if prefix is the beginning of key parts (or equal to them)
+func hasKeyPrefix(chunks, prefix []string, foldCase bool) bool {
+	if len(prefix) > len(chunks) {
+		return false
+	}
+	for i, p := range prefix {
+		if p != chunks[i] && !(foldCase && strings.EqualFold(p, chunks[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns copy of tree with value set by given key parts. Only nodes on the path are copied; non-section nodes
+// on the path are replaced with sections
+func overlayValue(node interface{}, chunks []string, value interface{}, f keyFormat) interface{} {
+	if len(chunks) == 0 {
+		return value
+	}
+	if a, ok := node.([]interface{}); ok {
+		if idx, err := strconv.Atoi(chunks[0]); err == nil && idx >= 0 && idx < len(a) {
+			cp := append([]interface{}{}, a...)
+			cp[idx] = overlayValue(a[idx], chunks[1:], value, f)
+			return cp
+		}
+	}
+	m := toStrMap(node)
+	cp := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		cp[k] = v
+	}
+	key, child, _ := mapLookup(cp, chunks[0], f.foldCase)
+	cp[key] = overlayValue(child, chunks[1:], value, f)
+	return cp
+}
@@ -0,0 +1,167 @@
+package conf8n
+
+import (
+	"sync"
+	"testing"
+)
+
+func overrideTestConfig() *Config {
+	return NewConfig(map[string]interface{}{
+		"db": map[string]interface{}{"host": "db.local", "port": 5432},
+		"feature": map[string]interface{}{
+			"x": false,
+		},
+		"list": []interface{}{"a", "b"},
+	})
+}
+
+func TestPushOverrides(t *testing.T) {
+	c := overrideTestConfig()
+	scope := c.PushOverrides(map[string]interface{}{
+		"db.host":     "override.local",
+		"feature.x":   true,
+		"feature.new": "added",
+		"list.1":      "B",
+	})
+	want := map[string]interface{}{
+		"db.host": "override.local", "db.port": 5432, "feature.x": true, "feature.new": "added", "list.1": "B",
+	}
+	for k, w := range want {
+		if got := c.Get(k).Raw(); got != w {
+			t.Errorf("%s: got %v, want %v", k, got, w)
+		}
+	}
+	for _, k := range c.AllKeys() {
+		if k == "feature.new" {
+			t.Errorf("Overrides are not expected to be seen by iteration over keys")
+		}
+	}
+	if err := scope.Pop(); err != nil {
+		t.Fatal(err)
+	}
+	for k, w := range map[string]interface{}{"db.host": "db.local", "feature.x": false, "list.1": "b"} {
+		if got := c.Get(k).Raw(); got != w {
+			t.Errorf("%s after Pop(): got %v, want %v", k, got, w)
+		}
+	}
+	if c.Has("feature.new") {
+		t.Errorf("Added key is expected to be removed by Pop()")
+	}
+}
+
+func TestNestedOverrideScopes(t *testing.T) {
+	c := overrideTestConfig()
+	outer := c.PushOverrides(map[string]interface{}{
+		"db":      map[string]interface{}{"host": "outer.local"},
+		"db.port": 1,
+	})
+	inner := c.PushOverrides(map[string]interface{}{"db.host": "inner.local"})
+	steps := []struct {
+		pop        *OverrideScope
+		host, port interface{}
+	}{
+		{nil, "inner.local", 1},
+		{inner, "outer.local", 1},
+		{outer, "db.local", 5432},
+	}
+	for i, s := range steps {
+		if s.pop != nil {
+			if err := s.pop.Close(); err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+		}
+		if got := c.Get("db.host").Raw(); got != s.host {
+			t.Errorf("Step %d: db.host: got %v, want %v", i, got, s.host)
+		}
+		if got := c.Get("db.port").Raw(); got != s.port {
+			t.Errorf("Step %d: db.port: got %v, want %v", i, got, s.port)
+		}
+	}
+}
+
+func TestOverrideScopesArePoppedInReverseOrder(t *testing.T) {
+	c := overrideTestConfig()
+	outer := c.PushOverrides(map[string]interface{}{"db.host": "outer.local"})
+	inner := c.PushOverrides(map[string]interface{}{"db.host": "inner.local"})
+	if err := outer.Pop(); err == nil {
+		t.Errorf("Popping of outer scope before inner one is expected to fail")
+	}
+	if got := c.Get("db.host").String(); got != "inner.local" {
+		t.Errorf("Failed Pop() must not change overrides: got %q", got)
+	}
+	for i, s := range []*OverrideScope{inner, outer, outer, inner} {
+		if err := s.Pop(); err != nil {
+			t.Errorf("Pop %d: %v", i, err)
+		}
+	}
+	if got := c.Get("db.host").String(); got != "db.local" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestOverridesOfSubConfigs(t *testing.T) {
+	c := overrideTestConfig()
+	before, err := c.Sub("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := c.PushOverrides(map[string]interface{}{"db.host": "override.local"})
+	after, err := c.Sub("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, sub := range map[string]*Config{"before push": before, "after push": after} {
+		if got := sub.Get("host").String(); got != "override.local" {
+			t.Errorf("Sub-config, created %s: got %q", name, got)
+		}
+	}
+	if err := scope.Pop(); err != nil {
+		t.Fatal(err)
+	}
+	// keys of scope, pushed on sub-config, are relative to it
+	subScope := before.PushOverrides(map[string]interface{}{"port": 1})
+	if got := c.Get("db.port").Raw(); got != 1 {
+		t.Errorf("Override of sub-config is expected to be seen by parent: got %v", got)
+	}
+	if got := c.Get("port").Raw(); got != nil {
+		t.Errorf("Override of sub-config must not be set at top level: got %v", got)
+	}
+	if err := subScope.Pop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.Get("port").Raw(); got != 5432 {
+		t.Errorf("Got %v", got)
+	}
+}
+
+// Run with -race
+func TestConcurrentOverridesAndLookups(t *testing.T) {
+	c := overrideTestConfig()
+	sub, err := c.Sub("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if host := sub.Get("host").String(); host != "db.local" && host != "override.local" {
+					t.Errorf("Unexpected value %q", host)
+					return
+				}
+				c.Get("db.port").Int()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				// scopes of concurrent goroutines may interleave, so Pop() may fail here
+				scope := c.PushOverrides(map[string]interface{}{"db.host": "override.local"})
+				_ = scope.Pop()
+			}
+		}()
+	}
+	wg.Wait()
+}
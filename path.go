@@ -0,0 +1,46 @@
+package conf8n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Interprets value as filesystem path. Leading "~" is expanded to user's home directory,
+// environment variables ($VAR or ${VAR}) are substituted. If config was loaded from file,
+// relative path is resolved against the directory of that file
+func (v ConfigValue) Path() (string, error) {
+	p, err := v.MustString()
+	if err != nil {
+		return "", err
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		p = home + p[1:]
+	}
+	p = os.ExpandEnv(p)
+	if !filepath.IsAbs(p) && v.c != nil && v.c.source != "" {
+		p = filepath.Join(filepath.Dir(v.c.source), p)
+	}
+	return p, nil
+}
+
+// Same as Path(), but also reports error if path does not exist (or can't be accessed); error of os.Stat() is wrapped,
+// so errors.Is(err, fs.ErrNotExist) can be checked
+func (v ConfigValue) MustExistingPath() (string, error) {
+	p, err := v.Path()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("Path does not exist: %w", err)
+		}
+		return "", fmt.Errorf("Path can't be accessed: %w", err)
+	}
+	return p, nil
+}
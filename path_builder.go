@@ -0,0 +1,69 @@
+package conf8n
+
+import (
+	"strconv"
+)
+
+// Path to config value, built from key parts. Alternative to composite string keys: key parts are never split,
+// so they can contain any characters (including separator). Paths are comparable and can be used as map keys.
+//
+// Example:
+//
+//	conf.GetP(conf8n.P("servers").Idx(i).K("host"))
+type Path struct {
+	key string
+	n   int
+}
+
+// Creates path from given key parts
+func P(keys ...string) Path {
+	return Path{key: joinKeyChunks(keys, SEP), n: len(keys)}
+}
+
+// Parses composite key (with SEP as separator and escaping rules of Get()) to path
+func ParsePath(key string) Path {
+	return P(splitKey(key, SEP)...)
+}
+
+// Returns new path with given key part appended
+func (p Path) K(key string) Path {
+	return Path{key: joinKeyChunks(append(p.Segments(), key), SEP), n: p.n + 1}
+}
+
+// Returns new path with given slice index appended
+func (p Path) Idx(i int) Path {
+	return p.K(strconv.Itoa(i))
+}
+
+// Returns key parts of the path
+func (p Path) Segments() []string {
+	if p.n == 0 {
+		return []string{}
+	}
+	return splitKey(p.key, SEP)
+}
+
+// Returns length of the path (count of key parts)
+func (p Path) Len() int {
+	return p.n
+}
+
+// Returns path as composite key (with separators in key parts escaped), usable with Get()
+func (p Path) String() string {
+	return p.key
+}
+
+// Same as GetPath(), but path is given as Path
+func (c *Config) GetP(p Path) *ConfigValue {
+	return c.GetPath(p.Segments())
+}
+
+// Same as SetPath(), but path is given as Path
+func (c *Config) SetP(p Path, value interface{}) error {
+	return c.SetPath(p.Segments(), value)
+}
+
+// Same as HasPath(), but path is given as Path
+func (c *Config) HasP(p Path) bool {
+	return c.HasPath(p.Segments())
+}
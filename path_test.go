@@ -0,0 +1,29 @@
+package conf8n
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMustExistingPath(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(map[string]interface{}{"existing": existing, "missing": filepath.Join(dir, "missing.yaml")})
+	if p, err := c.Get("existing").MustExistingPath(); err != nil || p != existing {
+		t.Errorf("Existing path: got %q, %v", p, err)
+	}
+	_, err := c.Get("missing").MustExistingPath()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Missing path: error %v doesn't match fs.ErrNotExist", err)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != filepath.Join(dir, "missing.yaml") {
+		t.Errorf("Missing path: error %v is expected to wrap *fs.PathError", err)
+	}
+}
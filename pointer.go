@@ -0,0 +1,66 @@
+package conf8n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Get value by JSON Pointer (RFC 6901), like "/servers/0/host". Empty pointer refers to the whole config.
+// "~1" and "~0" sequences in pointer stand for "/" and "~" in keys. Array index "-" (past the last element)
+// and malformed pointers give empty value
+func (c *Config) GetPointer(pointer string) *ConfigValue {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return c.value(nil)
+	}
+	var node interface{} = c.tree()
+	for _, token := range tokens {
+		if a, ok := node.([]interface{}); ok {
+			idx, ok := pointerIndex(token)
+			if !ok || idx >= len(a) {
+				return c.value(nil)
+			}
+			node = a[idx]
+			continue
+		}
+		section := toStrMap(node)
+		if section == nil {
+			return c.value(nil)
+		}
+		v, found := section[token]
+		if !found {
+			return c.value(nil)
+		}
+		node = v
+	}
+	return &ConfigValue{v: node, c: c, key: joinKeyChunks(tokens, c.separator())}
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer must start with '/': %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// Parses array index token: it must be a non-negative number without leading zeros
+func pointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	idx, err := strconv.Atoi(token)
+	return idx, err == nil
+}
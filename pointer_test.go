@@ -0,0 +1,61 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPointer(t *testing.T) {
+	// example document of RFC 6901, section 5 (plus key "~1" to check order of unescaping)
+	c, err := NewConfigFromJson([]byte(`{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8,
+		"~1": 9
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pointer string
+		want    interface{}
+	}{
+		{"/foo", []interface{}{"bar", "baz"}},
+		{"/foo/0", "bar"},
+		{"/foo/1", "baz"},
+		{"/", 0.0},
+		{"/a~1b", 1.0},
+		{"/c%d", 2.0},
+		{"/e^f", 3.0},
+		{"/g|h", 4.0},
+		{`/i\j`, 5.0},
+		{`/k"l`, 6.0},
+		{"/ ", 7.0},
+		{"/m~0n", 8.0},
+		{"/~01", 9.0},
+		// not set
+		{"/foo/-", nil},
+		{"/foo/2", nil},
+		{"/foo/01", nil},
+		{"/foo/-1", nil},
+		{"/foo/0/x", nil},
+		{"/a/b", nil},
+		{"/m~n/x", nil},
+		{"foo", nil},
+	}
+	for _, tt := range tests {
+		if got := c.GetPointer(tt.pointer).Raw(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetPointer(%q): got %#v, want %#v", tt.pointer, got, tt.want)
+		}
+	}
+	if got := c.GetPointer("").Raw(); !reflect.DeepEqual(got, c.tree()) {
+		t.Errorf("Empty pointer is expected to refer to the whole document, got %#v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package conf8n
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Maximal growth of polling interval on consecutive failures (see WatchURL())
+const maxPollBackoff = 32
+
+// HTTP client, used by WatchURL()
+var PollClient = http.DefaultClient
+
+// Loads config from URL and starts polling it with given interval (randomly varied by ±10%, so many instances
+// don't poll simultaneously). Format of data is defined by Content-Type of response or by extension of URL path.
+// Conditional requests (with ETag and Last-Modified of the last response) are used, so unchanged data is not
+// downloaded again; changes of data, that don't change config itself (like reformatting or reordering of keys,
+// see Config.Checksum()), are ignored. On every change onChange is called with the new config; if polling or
+// parsing fails, onChange is called with the error, the previous config stays active and polling interval is
+// doubled on every consecutive failure (up to 32 intervals). Watching stops, when context is canceled or
+// Watcher.Close() is called. Callbacks are called sequentially from the watching goroutine
+func WatchURL(ctx context.Context, url string, interval time.Duration, onChange func(c *Config, err error), opts ...LoadOption) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("Polling interval must be positive, got %v", interval)
+	}
+	uw := &urlWatch{url: url, interval: interval, opts: opts}
+	c, err := uw.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uw.checksum = c.Checksum()
+	w, ctx := newWatcher(ctx, c, onChange)
+	uw.w = w
+	go uw.run(ctx)
+	return w, nil
+}
+
+// State of URL polling
+type urlWatch struct {
+	w            *Watcher
+	url          string
+	interval     time.Duration
+	opts         []LoadOption
+	etag         string
+	lastModified string
+	checksum     string
+	failures     int
+}
+
+func (uw *urlWatch) run(ctx context.Context) {
+	defer close(uw.w.done)
+	timer := time.NewTimer(uw.delay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		c, err := uw.fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err != nil:
+			uw.failures++
+			uw.w.onChange(nil, err)
+		case c == nil:
+			uw.failures = 0
+		default:
+			uw.failures = 0
+			if checksum := c.Checksum(); checksum != uw.checksum {
+				uw.checksum = checksum
+				uw.w.update(c)
+			}
+		}
+		timer.Reset(uw.delay())
+	}
+}
+
+// Returns delay before the next poll
+func (uw *urlWatch) delay() time.Duration {
+	d := uw.interval
+	for i := 0; i < uw.failures && d < uw.interval*maxPollBackoff; i++ {
+		d *= 2
+	}
+	jitter := time.Duration((rand.Float64()*0.2 - 0.1) * float64(d))
+	return d + jitter
+}
+
+// Requests config data. Returns nil config, if data is not modified since the previous request
+func (uw *urlWatch) fetch(ctx context.Context) (*Config, error) {
+	req, err := http.NewRequest(http.MethodGet, uw.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if uw.etag != "" {
+		req.Header.Set("If-None-Match", uw.etag)
+	}
+	if uw.lastModified != "" {
+		req.Header.Set("If-Modified-Since", uw.lastModified)
+	}
+	resp, err := PollClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Config request to %s failed: %s", uw.url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var c *Config
+	switch format := uw.format(resp); format {
+	case JSON:
+		c, err = newConfigFromData(data, JSON, uw.url, uw.opts)
+	case YAML:
+		c, err = newConfigFromData(data, YAML, uw.url, uw.opts)
+	default:
+		err = fmt.Errorf("Unknown config format of %s (Content-Type '%s')", uw.url, resp.Header.Get("Content-Type"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	uw.etag = resp.Header.Get("ETag")
+	uw.lastModified = resp.Header.Get("Last-Modified")
+	return c, nil
+}
+
+// Defines format of response data
+func (uw *urlWatch) format(resp *http.Response) string {
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		switch {
+		case strings.HasSuffix(mediaType, "json"):
+			return JSON
+		case strings.HasSuffix(mediaType, "yaml"), strings.HasSuffix(mediaType, "yml"):
+			return YAML
+		}
+	}
+	if u, err := url.Parse(uw.url); err == nil {
+		return strings.TrimLeft(strings.ToLower(path.Ext(u.Path)), ".")
+	}
+	return ""
+}
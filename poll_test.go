@@ -0,0 +1,162 @@
+package conf8n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTP handler, serving config document with ETag
+type configServer struct {
+	mu          sync.Mutex
+	body        string
+	contentType string
+	etag        string
+	status      int
+	conditional int
+}
+
+func (s *configServer) set(body, etag string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag, s.status = body, etag, status
+}
+
+func (s *configServer) conditionalRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conditional
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("If-None-Match") != "" {
+		s.conditional++
+		if r.Header.Get("If-None-Match") == s.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	if s.status != http.StatusOK {
+		w.WriteHeader(s.status)
+		return
+	}
+	if s.contentType != "" {
+		w.Header().Set("Content-Type", s.contentType)
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Write([]byte(s.body))
+}
+
+func TestWatchURL(t *testing.T) {
+	cs := &configServer{contentType: "application/json", body: `{"v": "a"}`, etag: `"1"`, status: http.StatusOK}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	results := make(chan watchResult, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := WatchURL(ctx, srv.URL+"/config", 10*time.Millisecond, func(c *Config, err error) {
+		results <- watchResult{c, err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Config().Get("v").String(); got != "a" {
+		t.Fatalf("Initial config: got v=%q", got)
+	}
+	// unchanged data is not reported
+	for deadline := time.Now().Add(5 * time.Second); cs.conditionalRequests() < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("Conditional requests are expected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// reformatting of data doesn't change config
+	cs.set(`{ "v" : "a" }`, `"2"`, http.StatusOK)
+	select {
+	case res := <-results:
+		t.Fatalf("Unchanged config is not expected to be reported, got %v, %v", res.c, res.err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cs.set(`{"v": "b"}`, `"3"`, http.StatusOK)
+	if res := nextResult(t, results); res.err != nil || res.c.Get("v").String() != "b" {
+		t.Fatalf("Got %v, %v", res.c, res.err)
+	}
+	cs.set("", `"4"`, http.StatusInternalServerError)
+	if res := nextResult(t, results); res.err == nil {
+		t.Errorf("Failed request is expected to be reported")
+	}
+	if got := w.Config().Get("v").String(); got != "b" {
+		t.Errorf("Previous config is expected to stay active, got v=%q", got)
+	}
+	w.Close()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Polling is not stopped")
+	}
+}
+
+func TestWatchURLFormats(t *testing.T) {
+	tests := []struct {
+		path, contentType string
+		ok                bool
+	}{
+		{"/config", "application/json; charset=utf-8", true},
+		{"/config", "application/yaml", true},
+		{"/config", "text/x-yaml", true},
+		{"/config.yaml", "", true},
+		{"/config.json", "text/plain", true},
+		{"/config", "text/plain", false},
+	}
+	for _, tt := range tests {
+		cs := &configServer{contentType: tt.contentType, body: `{"v": "a"}`, status: http.StatusOK}
+		srv := httptest.NewServer(cs)
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := WatchURL(ctx, srv.URL+tt.path, time.Hour, func(*Config, error) {})
+		if tt.ok && (err != nil || w.Config().Get("v").String() != "a") {
+			t.Errorf("%s (%s): unexpected error %v", tt.path, tt.contentType, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s (%s): unknown format is expected to be reported", tt.path, tt.contentType)
+		}
+		cancel()
+		srv.Close()
+	}
+}
+
+func TestWatchURLErrors(t *testing.T) {
+	if _, err := WatchURL(context.Background(), "http://localhost/", 0, func(*Config, error) {}); err == nil {
+		t.Errorf("Non-positive interval is expected to be reported")
+	}
+	cs := &configServer{status: http.StatusNotFound}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	if _, err := WatchURL(context.Background(), srv.URL+"/config.json", time.Second, func(*Config, error) {}); err == nil {
+		t.Errorf("Failed initial request is expected to be reported")
+	}
+}
+
+func TestPollBackoff(t *testing.T) {
+	uw := &urlWatch{interval: time.Second}
+	for _, tt := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{10, maxPollBackoff * time.Second},
+	} {
+		uw.failures = tt.failures
+		for i := 0; i < 10; i++ {
+			if d := uw.delay(); d < tt.want*9/10 || d > tt.want*11/10 {
+				t.Errorf("%d failures: got delay %v, want %v ±10%%", tt.failures, d, tt.want)
+			}
+		}
+	}
+}
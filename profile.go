@@ -0,0 +1,113 @@
+package conf8n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Layout of config with profiles (environment-specific sections), like:
+//
+//	defaults: {db: {host: localhost, pool: 5}}
+//	production: {db: {host: db.prod}}
+//	staging: {db: {host: db.staging}}
+type ProfileOptions struct {
+	// Key of section with defaults ("defaults" by default)
+	Defaults string
+	// Keys of profile sections. If empty, every top-level section (except defaults) is a profile
+	Profiles []string
+	// If true, top-level keys outside of defaults and profile sections are merged as additional defaults
+	// (under the defaults section); otherwise they are ignored
+	KeepOthers bool
+}
+
+// Returns config for given profile with default layout (see ProfileOptions)
+func (c *Config) Profile(name string) (*Config, error) {
+	return c.ProfileWith(name, ProfileOptions{})
+}
+
+// Returns config, where section of given profile is deep-merged over defaults section: sections are merged
+// recursively, other values of profile (including slices) replace default ones. Reports error, listing available
+// profiles, if profile is unknown. Original config is not changed
+func (c *Config) ProfileWith(name string, o ProfileOptions) (*Config, error) {
+	if o.Defaults == "" {
+		o.Defaults = "defaults"
+	}
+	profiles := c.profiles(o)
+	found := false
+	for _, p := range profiles {
+		found = found || p == name
+	}
+	if !found {
+		return nil, fmt.Errorf("Unknown profile '%s', available: %s", name, strings.Join(profiles, ", "))
+	}
+	var merged interface{} = map[string]interface{}{}
+	var err error
+	if o.KeepOthers {
+		others := make(map[string]interface{})
+		for k, v := range c.tree() {
+			if !isProfileKey(k, o.Defaults, profiles) {
+				others[k] = v
+			}
+		}
+		if merged, err = mergeTrees(merged, others); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range []string{o.Defaults, name} {
+		section := c.GetLiteral(key)
+		if section.IsSet() && !section.IsMap() {
+			return nil, c.keyError(key, wrapf(ErrWrongType, "Value is a %s, not a section", kindOf(section.v)))
+		}
+		if !section.IsSet() {
+			continue
+		}
+		if merged, err = mergeTrees(merged, section.v); err != nil {
+			return nil, c.keyError(key, err)
+		}
+	}
+	return c.sub(merged.(map[string]interface{}), nil), nil
+}
+
+// Same as Profile(), but name of profile is taken from given environment variable (like "APP_ENV").
+// Reports error if variable is not set
+func (c *Config) ProfileFromEnv(envVar string, o ProfileOptions) (*Config, error) {
+	name := os.Getenv(envVar)
+	if name == "" {
+		return nil, fmt.Errorf("Environment variable %s is not set, available profiles: %s",
+			envVar, strings.Join(c.profiles(o), ", "))
+	}
+	return c.ProfileWith(name, o)
+}
+
+// Returns sorted names of profiles
+func (c *Config) profiles(o ProfileOptions) []string {
+	if o.Defaults == "" {
+		o.Defaults = "defaults"
+	}
+	if len(o.Profiles) > 0 {
+		profiles := append([]string{}, o.Profiles...)
+		sort.Strings(profiles)
+		return profiles
+	}
+	var profiles []string
+	for _, k := range mapSortedKeys(c.tree()) {
+		if k != o.Defaults && kindOf(c.tree()[k]) == Map {
+			profiles = append(profiles, k)
+		}
+	}
+	return profiles
+}
+
+func isProfileKey(key, defaults string, profiles []string) bool {
+	if key == defaults {
+		return true
+	}
+	for _, p := range profiles {
+		if key == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package conf8n
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func profilesTree() map[string]interface{} {
+	return map[string]interface{}{
+		"defaults":   map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "pool": 5}, "tags": []interface{}{"a"}},
+		"production": map[string]interface{}{"db": map[string]interface{}{"host": "db.prod"}, "tags": []interface{}{"b", "c"}},
+		"staging":    map[string]interface{}{"db": map[string]interface{}{"host": "db.staging"}},
+		"version":    "1.2",
+	}
+}
+
+func TestProfile(t *testing.T) {
+	c := NewConfig(profilesTree())
+	prod, err := c.Profile("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"db":   map[string]interface{}{"host": "db.prod", "pool": 5},
+		"tags": []interface{}{"b", "c"},
+	}
+	if !reflect.DeepEqual(prod.tree(), want) {
+		t.Errorf("got %v, want %v", prod.tree(), want)
+	}
+	if host := c.Get("defaults.db.host").String(); host != "localhost" {
+		t.Errorf("Original config is changed: %s", host)
+	}
+	staging, err := c.ProfileWith("staging", ProfileOptions{KeepOthers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := staging.Get("version").String(); v != "1.2" {
+		t.Errorf("Top-level key is not kept: %q", v)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		o    ProfileOptions
+		want string
+	}{
+		{"dev", ProfileOptions{}, "Unknown profile 'dev', available: production, staging"},
+		{"defaults", ProfileOptions{}, "Unknown profile 'defaults', available: production, staging"},
+		{"version", ProfileOptions{}, "Unknown profile 'version', available: production, staging"},
+		{"production", ProfileOptions{Defaults: "production"}, "Unknown profile 'production', available: defaults, staging"},
+		{"staging", ProfileOptions{Profiles: []string{"production", "dev"}}, "Unknown profile 'staging', available: dev, production"},
+		{"", ProfileOptions{}, "Unknown profile '', available: production, staging"},
+	}
+	c := NewConfig(profilesTree())
+	for _, test := range tests {
+		_, err := c.ProfileWith(test.name, test.o)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.want)
+		}
+	}
+	if _, err := c.ProfileWith("version", ProfileOptions{Profiles: []string{"version"}}); !errors.Is(err, ErrWrongType) {
+		t.Errorf("ErrWrongType expected for scalar profile section, got %v", err)
+	}
+	if p, err := c.ProfileWith("dev", ProfileOptions{Profiles: []string{"dev"}}); err != nil || p.Get("db.host").String() != "localhost" {
+		t.Errorf("Listed profile without section is expected to give defaults, got %v", err)
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	c := NewConfig(profilesTree())
+	os.Unsetenv("CONF8N_TEST_ENV")
+	_, err := c.ProfileFromEnv("CONF8N_TEST_ENV", ProfileOptions{})
+	if err == nil || !strings.Contains(err.Error(), "CONF8N_TEST_ENV is not set, available profiles: production, staging") {
+		t.Errorf("Error of unset variable expected, got %v", err)
+	}
+	os.Setenv("CONF8N_TEST_ENV", "qa")
+	defer os.Unsetenv("CONF8N_TEST_ENV")
+	if _, err := c.ProfileFromEnv("CONF8N_TEST_ENV", ProfileOptions{}); err == nil || !strings.Contains(err.Error(), "Unknown profile 'qa'") {
+		t.Errorf("Error of unknown profile expected, got %v", err)
+	}
+	os.Setenv("CONF8N_TEST_ENV", "staging")
+	p, err := c.ProfileFromEnv("CONF8N_TEST_ENV", ProfileOptions{})
+	if err != nil || p.Get("db.host").String() != "db.staging" {
+		t.Errorf("Profile of variable is not selected: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package conf8n
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Labels of sources of leaf values (keyed by paths from the root config), shared by config and its sub-configs.
+// Empty label means, that source is unknown
+type provenance struct {
+	mu     sync.Mutex
+	labels map[string]string
+}
+
+// Deep-merges other config into c (in place) and returns c itself: sections are merged recursively, other values
+// of other (including slices and nulls) replace values of c. If source is not empty, it is recorded as origin of
+// every merged value (see Explain()). Values, replaced by later merges, get source of the new value; values,
+// changed with Set(), get unknown source. Sub-configs, created after the first Merge() call, share recorded
+// sources with their parent. Sections, nested deeper than MaxDepth, are replaced instead of merging
+func (c *Config) Merge(other *Config, source string) *Config {
+	if other == nil {
+		return c
+	}
+	c.detach()
+	if c.tree() == nil {
+		c.data = make(map[string]interface{})
+	}
+	_ = mergeInto(c.tree(), other.tree(), nil)
+	c.invalidate()
+	if source != "" || c.provenance != nil {
+		c.recordProvenance(nil, other.tree(), source)
+	}
+	return c
+}
+
+// Merges src into dst in place (see Merge()). Key parts of dst are used for error message; sections,
+// nested deeper than MaxDepth, are replaced by ones of src (ErrMaxDepthExceeded is returned)
+func mergeInto(dst, src map[string]interface{}, chunks []string) error {
+	var err, childErr error
+	for k, v := range src {
+		childChunks := append(chunks, k)
+		current, isMap := dst[k].(map[string]interface{})
+		switch ms := toStrMap(v); {
+		case isMap && ms != nil && len(childChunks) < MaxDepth:
+			childErr = mergeInto(current, ms, childChunks)
+		case isMap && ms != nil:
+			dst[k], childErr = v, maxDepthErr(joinKeyChunks(childChunks, SEP))
+		default:
+			dst[k], childErr = deepCopyDepth(v, childChunks)
+		}
+		if err == nil {
+			err = childErr
+		}
+	}
+	return err
+}
+
+// Records source of every leaf of value, set by given key parts (relative to c). Records of replaced
+// values (sections, replaced by scalars, and vice versa) are dropped
+func (c *Config) recordProvenance(chunks []string, value interface{}, source string) {
+	if c.provenance == nil {
+		c.provenance = &provenance{labels: make(map[string]string)}
+	}
+	p := c.provenance
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prefix := append(append([]string{}, c.path...), chunks...)
+	leaves := make(map[string]string)
+	if len(chunks) > 0 && isLeaf(value, false) {
+		leaves[joinKeyChunks(prefix, SEP)] = source
+	} else {
+		// leaves, nested deeper than MaxDepth, are not recorded
+		_ = walkLeaves(value, joinKeyChunks(prefix, SEP), SEP, false, func(path string, _ interface{}) {
+			leaves[path] = source
+		})
+	}
+	// Records inside of sections, replaced by scalars
+	for k := range p.labels {
+		if hasAncestor(k, leaves) {
+			delete(p.labels, k)
+		}
+	}
+	for path, label := range leaves {
+		// Records of scalars, replaced by sections
+		for i := strings.Index(path, SEP); i >= 0; i = nextSep(path, i) {
+			delete(p.labels, path[:i])
+		}
+		p.labels[path] = label
+	}
+}
+
+// Checks if some of proper ancestors of the path (like "a" and "a.b" for "a.b.c") is key of m
+func hasAncestor(path string, m map[string]string) bool {
+	for i := strings.Index(path, SEP); i >= 0; i = nextSep(path, i) {
+		if _, found := m[path[:i]]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns index of separator after the one at index i (-1 if there is no more)
+func nextSep(path string, i int) int {
+	if j := strings.Index(path[i+len(SEP):], SEP); j >= 0 {
+		return i + len(SEP) + j
+	}
+	return -1
+}
+
+// Marks source of value, set by given key parts, as unknown
+func (c *Config) forgetProvenance(chunks []string, value interface{}) {
+	if c.provenance != nil {
+		c.recordProvenance(chunks, value, "")
+	}
+}
+
+// Returns value of key (see Get() for key format) with label of its source, as given to Merge(). If value was
+// not merged from labeled source, source is the file config was loaded from (or empty string, if it's unknown).
+// For sections, sorted comma-separated list of sources of their values is returned. Keys are matched relative
+// to the config, but sources are shared with the root config, so sub-configs report the same sources.
+// Flag is false, if key is not set
+func (c *Config) Explain(key string) (value interface{}, source string, ok bool) {
+	value, err := c.lookupKey(key)
+	if err != nil {
+		return nil, "", false
+	}
+	if c.provenance == nil {
+		return value, c.source, true
+	}
+	full := c.fullKey(key)
+	if isLeaf(value, false) {
+		return value, c.sourceLabel(full), true
+	}
+	labels := make(map[string]bool)
+	// sources of leaves, nested deeper than MaxDepth, are not listed
+	_ = walkLeaves(value, full, SEP, false, func(path string, _ interface{}) {
+		if label := c.sourceLabel(path); label != "" {
+			labels[label] = true
+		}
+	})
+	sources := make([]string, 0, len(labels))
+	for label := range labels {
+		sources = append(sources, label)
+	}
+	sort.Strings(sources)
+	return value, strings.Join(sources, ", "), true
+}
+
+// Returns sources of all leaf values of config (keyed as AllKeys() does), see Explain(). Values with unknown
+// source (and leaves, nested deeper than MaxDepth, see ProvenanceE()) are omitted
+func (c *Config) Provenance() map[string]string {
+	res, _ := c.ProvenanceE()
+	return res
+}
+
+// Same as Provenance(), but reports error (wrapping ErrMaxDepthExceeded), if config is nested deeper than MaxDepth.
+// Sources, found before that, are returned too
+func (c *Config) ProvenanceE() (map[string]string, error) {
+	res := make(map[string]string)
+	sep := c.separator()
+	err := walkLeaves(c.tree(), "", sep, false, func(path string, _ interface{}) {
+		label := c.source
+		if c.provenance != nil {
+			chunks := append(append([]string{}, c.path...), splitKey(path, sep)...)
+			label = c.sourceLabel(joinKeyChunks(chunks, SEP))
+		}
+		if label != "" {
+			res[path] = label
+		}
+	})
+	return res, err
+}
+
+// Returns recorded source of leaf by its path from the root config
+func (c *Config) sourceLabel(path string) string {
+	c.provenance.mu.Lock()
+	defer c.provenance.mu.Unlock()
+	if label, found := c.provenance.labels[path]; found {
+		return label
+	}
+	return c.source
+}
@@ -0,0 +1,46 @@
+package conf8n
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProvenanceOfReplacedValues(t *testing.T) {
+	c := NewConfig(nil)
+	c.Merge(NewConfig(map[string]interface{}{
+		"db":  map[string]interface{}{"host": "a", "pool": map[string]interface{}{"size": 1}},
+		"log": "info",
+	}), "base.yaml")
+	c.Merge(NewConfig(map[string]interface{}{
+		"db":  map[string]interface{}{"pool": 10},
+		"log": map[string]interface{}{"level": "debug"},
+	}), "local.yaml")
+	want := map[string]string{"db.host": "base.yaml", "db.pool": "local.yaml", "log.level": "local.yaml"}
+	if got := c.Provenance(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if err := c.Set("db", "postgres://"); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"log.level": "local.yaml"}
+	if got := c.Provenance(); !reflect.DeepEqual(got, want) {
+		t.Errorf("After Set(): got %v, want %v", got, want)
+	}
+}
+
+func BenchmarkMergeProvenance(b *testing.B) {
+	tree := make(map[string]interface{})
+	for i := 0; i < 100; i++ {
+		section := make(map[string]interface{})
+		for j := 0; j < 100; j++ {
+			section[fmt.Sprint("key", j)] = j
+		}
+		tree[fmt.Sprint("section", i)] = section
+	}
+	other := NewConfig(tree)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewConfig(nil).Merge(other, "base.yaml").Merge(other, "local.yaml")
+	}
+}
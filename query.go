@@ -0,0 +1,410 @@
+package conf8n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Returns values, matching JSONPath-like expression. Supported subset of JSONPath:
+//
+//	$                   root of config
+//	.key or ['key']     child of map
+//	.* or [*]           all children of map or slice
+//	[n]                 element of slice (negative index counts from the end)
+//	[start:end:step]    elements of slice in range (any part can be omitted)
+//	[?(@.key=='value')] elements of map or slice, whose field is equal (==) or not equal (!=) to given literal;
+//	                    literal can be a quoted string, number, true, false or null
+//
+// Example: conf.Query("$.servers[?(@.role=='primary')].host").
+// Reports error, pointing to the position of the problem, if expression can't be parsed
+func (c *Config) Query(expr string) ([]*ConfigValue, error) {
+	segments, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	type match struct {
+		path string
+		v    interface{}
+	}
+	sep := c.separator()
+	matches := []match{{"", c.tree()}}
+	for _, seg := range segments {
+		var next []match
+		for _, m := range matches {
+			seg.apply(m.v, func(segment string, v interface{}) {
+				next = append(next, match{joinKey(m.path, segment, sep), v})
+			})
+		}
+		matches = next
+	}
+	values := make([]*ConfigValue, len(matches))
+	for i, m := range matches {
+		values[i] = &ConfigValue{v: m.v, c: c, key: m.path}
+	}
+	return values, nil
+}
+
+type querySegment interface {
+	// Calls emit for every child of node, selected by segment
+	apply(node interface{}, emit func(segment string, v interface{}))
+}
+
+type queryChild string
+
+type queryWildcard struct{}
+
+type queryIndex int
+
+type querySlice struct {
+	start, end, step int
+	hasStart, hasEnd bool
+}
+
+type queryFilter struct {
+	field []string
+	op    string
+	value interface{}
+}
+
+func (q queryChild) apply(node interface{}, emit func(segment string, v interface{})) {
+	if m := toStrMap(node); m != nil {
+		if v, found := m[string(q)]; found {
+			emit(string(q), v)
+		}
+	}
+}
+
+func (q queryWildcard) apply(node interface{}, emit func(segment string, v interface{})) {
+	segments, values := childrenOf(node)
+	for i, v := range values {
+		emit(segments[i], v)
+	}
+}
+
+func (q queryIndex) apply(node interface{}, emit func(segment string, v interface{})) {
+	a, ok := node.([]interface{})
+	if !ok {
+		return
+	}
+	idx := int(q)
+	if idx < 0 {
+		idx += len(a)
+	}
+	if idx >= 0 && idx < len(a) {
+		emit(strconv.Itoa(idx), a[idx])
+	}
+}
+
+func (q querySlice) apply(node interface{}, emit func(segment string, v interface{})) {
+	a, ok := node.([]interface{})
+	if !ok || q.step == 0 {
+		return
+	}
+	norm := func(i int) int {
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 {
+			return 0
+		}
+		if i > len(a) {
+			return len(a)
+		}
+		return i
+	}
+	if q.step > 0 {
+		start, end := 0, len(a)
+		if q.hasStart {
+			start = norm(q.start)
+		}
+		if q.hasEnd {
+			end = norm(q.end)
+		}
+		for i := start; i < end; i += q.step {
+			emit(strconv.Itoa(i), a[i])
+		}
+		return
+	}
+	// for negative step bounds are clamped to [-1, len-1], so out-of-range end (like -100) includes element 0
+	normDown := func(i int) int {
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 {
+			return -1
+		}
+		if i >= len(a) {
+			return len(a) - 1
+		}
+		return i
+	}
+	start, end := len(a)-1, -1
+	if q.hasStart {
+		start = normDown(q.start)
+	}
+	if q.hasEnd {
+		end = normDown(q.end)
+	}
+	for i := start; i > end; i += q.step {
+		emit(strconv.Itoa(i), a[i])
+	}
+}
+
+func (q queryFilter) apply(node interface{}, emit func(segment string, v interface{})) {
+	segments, values := childrenOf(node)
+	for i, v := range values {
+		field, err := getValueWithCompositeKey(v, q.field, keyFormat{sep: SEP})
+		if err != nil {
+			continue
+		}
+		if looseEqual(field, q.value) == (q.op == "==") {
+			emit(segments[i], v)
+		}
+	}
+}
+
+// Compares scalar values; numbers are compared regardless of their representation (int or float)
+func looseEqual(a, b interface{}) bool {
+	if na, ok := toNumber(a); ok {
+		nb, ok := toNumber(b)
+		return ok && na == nb
+	}
+	switch a.(type) {
+	case nil, bool, string:
+		return a == b
+	}
+	return false
+}
+
+type queryParser struct {
+	expr string
+	pos  int
+}
+
+func parseQuery(expr string) ([]querySegment, error) {
+	p := &queryParser{expr: expr}
+	if !p.consume("$") {
+		return nil, p.errorf("expression must start with '$'")
+	}
+	var segments []querySegment
+	for p.pos < len(p.expr) {
+		var seg querySegment
+		var err error
+		switch p.expr[p.pos] {
+		case '.':
+			seg, err = p.parseDot()
+		case '[':
+			seg, err = p.parseBracket()
+		default:
+			err = p.errorf("unexpected '%c'", p.expr[p.pos])
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+func (p *queryParser) parseDot() (querySegment, error) {
+	p.pos++
+	if p.consume("*") {
+		return queryWildcard{}, nil
+	}
+	if p.pos < len(p.expr) && p.expr[p.pos] == '.' {
+		return nil, p.errorf("recursive descent ('..') is not supported")
+	}
+	name := p.identifier()
+	if name == "" {
+		return nil, p.errorf("key expected")
+	}
+	return queryChild(name), nil
+}
+
+func (p *queryParser) parseBracket() (querySegment, error) {
+	p.pos++
+	p.skipSpaces()
+	var seg querySegment
+	switch {
+	case p.consume("*"):
+		seg = queryWildcard{}
+	case p.consume("?"):
+		filter, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		seg = filter
+	case p.pos < len(p.expr) && (p.expr[p.pos] == '\'' || p.expr[p.pos] == '"'):
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		seg = queryChild(s)
+	default:
+		sel, err := p.parseIndexOrSlice()
+		if err != nil {
+			return nil, err
+		}
+		seg = sel
+	}
+	p.skipSpaces()
+	if !p.consume("]") {
+		return nil, p.errorf("']' expected")
+	}
+	return seg, nil
+}
+
+func (p *queryParser) parseIndexOrSlice() (querySegment, error) {
+	var parts []string
+	start := p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] != ']' {
+		p.pos++
+	}
+	parts = strings.Split(p.expr[start:p.pos], ":")
+	if len(parts) > 3 {
+		p.pos = start
+		return nil, p.errorf("invalid slice")
+	}
+	nums := make([]int, len(parts))
+	has := make([]bool, len(parts))
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid index '%s'", part)
+		}
+		nums[i], has[i] = n, true
+	}
+	if len(parts) == 1 {
+		if !has[0] {
+			p.pos = start
+			return nil, p.errorf("index expected")
+		}
+		return queryIndex(nums[0]), nil
+	}
+	q := querySlice{start: nums[0], hasStart: has[0], end: nums[1], hasEnd: has[1], step: 1}
+	if len(parts) == 3 && has[2] {
+		q.step = nums[2]
+	}
+	return q, nil
+}
+
+func (p *queryParser) parseFilter() (querySegment, error) {
+	if !p.consume("(") {
+		return nil, p.errorf("'(' expected")
+	}
+	p.skipSpaces()
+	if !p.consume("@") {
+		return nil, p.errorf("'@' expected")
+	}
+	var field []string
+	for p.consume(".") {
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("key expected")
+		}
+		field = append(field, name)
+	}
+	if len(field) == 0 {
+		return nil, p.errorf("'.' expected")
+	}
+	p.skipSpaces()
+	var op string
+	switch {
+	case p.consume("=="):
+		op = "=="
+	case p.consume("!="):
+		op = "!="
+	default:
+		return nil, p.errorf("'==' or '!=' expected")
+	}
+	p.skipSpaces()
+	value, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.consume(")") {
+		return nil, p.errorf("')' expected")
+	}
+	return queryFilter{field: field, op: op, value: value}, nil
+}
+
+func (p *queryParser) literal() (interface{}, error) {
+	if p.pos < len(p.expr) && (p.expr[p.pos] == '\'' || p.expr[p.pos] == '"') {
+		return p.quoted()
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(" )", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	token := p.expr[start:p.pos]
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(token, 64); err == nil {
+		return f, nil
+	}
+	p.pos = start
+	return nil, p.errorf("literal expected")
+}
+
+func (p *queryParser) quoted() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.expr) {
+		ch := p.expr[p.pos]
+		p.pos++
+		switch {
+		case ch == quote:
+			return b.String(), nil
+		case ch == '\\' && p.pos < len(p.expr):
+			b.WriteByte(p.expr[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *queryParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.expr) {
+		ch := p.expr[p.pos]
+		if ch == '.' || ch == '[' || ch == ']' || ch == ' ' || ch == '=' || ch == '!' || ch == ')' {
+			break
+		}
+		p.pos++
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *queryParser) consume(token string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) skipSpaces() {
+	for p.pos < len(p.expr) && p.expr[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Query parse error at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,118 @@
+package conf8n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuerySlice(t *testing.T) {
+	c := NewConfig(map[string]interface{}{"a": []interface{}{0, 1, 2, 3, 4}})
+	// expected results are the same as of Python slices of [0, 1, 2, 3, 4]
+	tests := map[string][]interface{}{
+		"$.a[1:3]":       {1, 2},
+		"$.a[-2:]":       {3, 4},
+		"$.a[:100]":      {0, 1, 2, 3, 4},
+		"$.a[::2]":       {0, 2, 4},
+		"$.a[-100:2]":    {0, 1},
+		"$.a[::-1]":      {4, 3, 2, 1, 0},
+		"$.a[3:0:-1]":    {3, 2, 1},
+		"$.a[3:-100:-1]": {3, 2, 1, 0},
+		"$.a[100::-2]":   {4, 2, 0},
+		"$.a[-100::-1]":  nil,
+		"$.a[2:-1:-1]":   nil,
+		"$.a[1:1]":       nil,
+	}
+	for expr, want := range tests {
+		values, err := c.Query(expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		var got []interface{}
+		for _, v := range values {
+			got = append(got, v.Raw())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "role": "primary", "port": 80, "tls": true, "meta": map[string]interface{}{"dc": "east"}},
+			map[string]interface{}{"host": "b", "role": "replica", "port": 8080.0, "tls": false, "backup": nil},
+			map[string]interface{}{"host": "c", "role": "replica", "port": "80"},
+		},
+		"pools": map[string]interface{}{
+			"x": map[string]interface{}{"size": 1},
+			"y": map[string]interface{}{"size": 2},
+		},
+	})
+	tests := []struct {
+		expr string
+		keys []string
+	}{
+		{"$.servers[?(@.role=='primary')].host", []string{"servers.0.host"}},
+		{`$.servers[?(@.role == "replica")].host`, []string{"servers.1.host", "servers.2.host"}},
+		{"$.servers[?(@.role!='primary')]", []string{"servers.1", "servers.2"}},
+		{"$.servers[?(@.port==80)]", []string{"servers.0"}},
+		{"$.servers[?(@.port==8080)]", []string{"servers.1"}},
+		{"$.servers[?(@.port=='80')]", []string{"servers.2"}},
+		{"$.servers[?(@.tls==true)]", []string{"servers.0"}},
+		{"$.servers[?(@.tls!=true)]", []string{"servers.1"}},
+		{"$.servers[?(@.backup==null)]", []string{"servers.1"}},
+		{"$.servers[?(@.meta.dc=='east')].host", []string{"servers.0.host"}},
+		{"$.servers[?(@.role=='none')]", nil},
+		{"$.pools[?(@.size==2)]", []string{"pools.y"}},
+		{"$.pools[?(@.size!=2)].size", []string{"pools.x.size"}},
+	}
+	for _, test := range tests {
+		values, err := c.Query(test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		var keys []string
+		for _, v := range values {
+			keys = append(keys, v.key)
+		}
+		if !reflect.DeepEqual(keys, test.keys) {
+			t.Errorf("%s: got %v, want %v", test.expr, keys, test.keys)
+		}
+	}
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	c := NewConfig(map[string]interface{}{})
+	tests := map[string]string{
+		"servers":                   "position 0: expression must start with '$'",
+		"$servers":                  "position 1: unexpected 's'",
+		"$.":                        "position 2: key expected",
+		"$..host":                   "position 2: recursive descent ('..') is not supported",
+		"$.a[0":                     "position 5: ']' expected",
+		"$.a[x]":                    "position 4: invalid index 'x'",
+		"$.a[]":                     "position 4: index expected",
+		"$.a[1:2:3:4]":              "position 4: invalid slice",
+		"$.a['b":                    "position 6: unterminated string",
+		"$.a[?@.b==1]":              "position 5: '(' expected",
+		"$.a[?(b==1)]":              "position 6: '@' expected",
+		"$.a[?(@==1)]":              "position 7: '.' expected",
+		"$.a[?(@.b > 1)]":           "position 10: '==' or '!=' expected",
+		"$.a[?(@.b==x)]":            "position 11: literal expected",
+		"$.a[?(@.b==1 ]":            "position 13: ')' expected",
+		"$.a[?(@.b=='c')].d[?(@.)]": "position 23: key expected",
+	}
+	for expr, want := range tests {
+		_, err := c.Query(expr)
+		if err == nil {
+			t.Errorf("%s: error expected", expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %q, want it to contain %q", expr, err, want)
+		}
+	}
+}
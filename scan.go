@@ -0,0 +1,226 @@
+package conf8n
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Stores value into variable, pointed by dest (similar to sql.Rows.Scan()).
+// Supported destinations are pointers to basic types (strings, bools, ints, uints, floats), time.Duration
+// (set as string like "1m30s"), time.Time (set as RFC 3339 string), slices and string-keyed maps of them,
+// interface{} (stores raw value) and types, implementing encoding.TextUnmarshaler (for string values).
+// Integers are stored into integer and float destinations, if conversion is lossless; floats are not stored into
+// integer destinations, even if they have no fractional part (as Int() doesn't convert them; Decode() does).
+// Reports error, wrapping ErrNilDest, if dest is nil (or nil pointer), ErrNotPointer, if it is not a pointer,
+// and ErrWrongType, if value can't be stored into it
+func (v ConfigValue) Scan(dest interface{}) error {
+	if dest == nil {
+		return wrapf(ErrNilDest, "Scan destination is nil")
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr {
+		return wrapf(ErrNotPointer, "Scan destination is not a pointer: %T", dest)
+	}
+	if rv.IsNil() {
+		return wrapf(ErrNilDest, "Scan destination is nil")
+	}
+	if !v.IsSet() {
+		return v.notSetErr()
+	}
+	return scanInto(v.v, rv.Elem(), v.key, false)
+}
+
+// Stores src into dst; key (path of src) is used only to show (or redact) values in errors. If wholeFloats is true,
+// floats without fractional part are stored into integer destinations (JSON decoder gives all numbers as floats)
+func scanInto(src interface{}, dst reflect.Value, key string, wholeFloats bool) error {
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if s, ok := src.(string); ok {
+			return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}
+	}
+	switch dst.Type() {
+	case durationType:
+		if d, ok := toDuration(src); ok {
+			dst.SetInt(int64(d))
+			return nil
+		}
+		return incompatibleErr(src, dst, key)
+	case timeType:
+		if t, ok := toTime(src); ok {
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		}
+		return incompatibleErr(src, dst, key)
+	}
+	switch dst.Kind() {
+	case reflect.Interface:
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if !reflect.TypeOf(src).AssignableTo(dst.Type()) {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.Set(reflect.ValueOf(src))
+	case reflect.String:
+		s, ok := src.(string)
+		if !ok {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.SetString(s)
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt64(src, wholeFloats)
+		if !ok || dst.OverflowInt(i) {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, ok := toUint64(src, wholeFloats)
+		if !ok || dst.OverflowUint(u) {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat64(src)
+		if !ok || dst.OverflowFloat(f) {
+			return incompatibleErr(src, dst, key)
+		}
+		// integers must be represented exactly
+		if kindOf(src) == Int && dst.Kind() == reflect.Float32 && float64(float32(f)) != f {
+			return incompatibleErr(src, dst, key)
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		a, ok := src.([]interface{})
+		if !ok {
+			return incompatibleErr(src, dst, key)
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(a), len(a))
+		for i, el := range a {
+			if err := scanInto(el, slice.Index(i), joinKey(key, strconv.Itoa(i), SEP), wholeFloats); err != nil {
+				return fmt.Errorf("Element %d: %w", i, err)
+			}
+		}
+		dst.Set(slice)
+	case reflect.Map:
+		m := toStrMap(src)
+		if m == nil || dst.Type().Key().Kind() != reflect.String {
+			return incompatibleErr(src, dst, key)
+		}
+		res := reflect.MakeMapWithSize(dst.Type(), len(m))
+		for k, el := range m {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := scanInto(el, elem, joinKey(key, k, SEP), wholeFloats); err != nil {
+				return fmt.Errorf("Key '%s': %w", k, err)
+			}
+			res.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(res)
+	default:
+		return wrapf(ErrWrongType, "Unsupported scan destination type: %s", dst.Type())
+	}
+	return nil
+}
+
+func incompatibleErr(src interface{}, dst reflect.Value, key string) error {
+	return wrapf(ErrWrongType, "Can't scan %s value %s into %s", kindOf(src), showValue(key, src), dst.Type())
+}
+
+// Returns integer value (or float one without fractional part, if wholeFloats is true) as int64,
+// if it fits into int64
+func toInt64(value interface{}, wholeFloats bool) (int64, bool) {
+	switch n := value.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float64:
+		// NaN fails the second check, infinities - the third one
+		if !wholeFloats || n != math.Trunc(n) || n < math.MinInt64 || n >= -math.MinInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// Returns non-negative integer value (or float one without fractional part, if wholeFloats is true) as uint64,
+// if it fits into uint64
+func toUint64(value interface{}, wholeFloats bool) (uint64, bool) {
+	switch n := value.(type) {
+	case int:
+		return uint64(n), n >= 0
+	case int64:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case float64:
+		if !wholeFloats || n != math.Trunc(n) || n < 0 || n >= 1<<64 {
+			return 0, false
+		}
+		return uint64(n), true
+	}
+	return 0, false
+}
+
+// Returns numeric value as float64, if it is represented exactly
+func toFloat64(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return toFloat64(int64(n))
+	case int64:
+		f := float64(n)
+		return f, f < -math.MinInt64 && int64(f) == n
+	case uint64:
+		f := float64(n)
+		return f, f < 1<<64 && uint64(f) == n
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// Returns numeric value as float64 (used for lossless conversions between numeric types)
+func toNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
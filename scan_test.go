@@ -0,0 +1,93 @@
+package conf8n
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestScanNumbers(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		dest interface{}
+		want interface{}
+	}{
+		{9007199254740993, new(int64), int64(9007199254740993)},
+		{int64(math.MaxInt64), new(int64), int64(math.MaxInt64)},
+		{uint64(math.MaxUint64), new(uint64), uint64(math.MaxUint64)},
+		{uint64(math.MaxUint64), new(int64), nil},
+		{1e19, new(int64), nil},
+		// floats are not converted to integers, as Int() doesn't do it
+		{1e19, new(uint64), nil},
+		{-1, new(uint), nil},
+		{300, new(int8), nil},
+		{300, new(uint8), nil},
+		{2.0, new(int), nil},
+		{int64(2), new(int), 2},
+		{2.5, new(int), nil},
+		{math.NaN(), new(int), nil},
+		{math.Inf(1), new(uint64), nil},
+		{9007199254740993, new(float64), nil},
+		{16777217, new(float32), nil},
+		{5, new(float32), float32(5)},
+		{0.5, new(float64), 0.5},
+	}
+	for _, tt := range tests {
+		err := (ConfigValue{v: tt.src}).Scan(tt.dest)
+		if tt.want == nil {
+			if !errors.Is(err, ErrWrongType) {
+				t.Errorf("Scan(%v) into %T: got error %v, want ErrWrongType", tt.src, tt.dest, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan(%v) into %T: %v", tt.src, tt.dest, err)
+			continue
+		}
+		var got interface{}
+		switch d := tt.dest.(type) {
+		case *int:
+			got = *d
+		case *int64:
+			got = *d
+		case *uint64:
+			got = *d
+		case *float32:
+			got = *d
+		case *float64:
+			got = *d
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) into %T: got %v, want %v", tt.src, tt.dest, got, tt.want)
+		}
+	}
+}
+
+func TestScanDestinations(t *testing.T) {
+	v := ConfigValue{v: 80}
+	var n int
+	var s string
+	tests := []struct {
+		dest     interface{}
+		sentinel error
+	}{
+		{nil, ErrNilDest},
+		{(*int)(nil), ErrNilDest},
+		{n, ErrNotPointer},
+		{&s, ErrWrongType},
+	}
+	for _, tt := range tests {
+		err := v.Scan(tt.dest)
+		if !errors.Is(err, tt.sentinel) {
+			t.Errorf("Scan() into %T: got error %v, want %v", tt.dest, err, tt.sentinel)
+		}
+		for _, other := range []error{ErrNilDest, ErrNotPointer, ErrWrongType} {
+			if other != tt.sentinel && errors.Is(err, other) {
+				t.Errorf("Scan() into %T: error %v is not expected to match %v", tt.dest, err, other)
+			}
+		}
+	}
+	if err := v.Scan(&n); err != nil || n != 80 {
+		t.Errorf("Got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,537 @@
+package conf8n
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Describes expected shape of config: kinds of keys, required keys, default and allowed values.
+// Example:
+//
+//	s := conf8n.NewSchema()
+//	s.Key("server.port", conf8n.Int).Required().Range(1, 65535)
+//	s.Key("servers", conf8n.Slice).Each(hostSchema)
+//	err := s.Validate(config)
+type Schema struct {
+	keys   []*SchemaKey
+	rules  []func(c *Config) error
+	strict bool
+	ignore []string
+}
+
+// Rules for single key of Schema. Methods of SchemaKey return the key itself, so calls can be chained
+type SchemaKey struct {
+	key      string
+	desc     string
+	kind     ValueKind
+	required bool
+	def      interface{}
+	allowed  []interface{}
+	each     *Schema
+	checks   []schemaCheck
+	warn     bool
+}
+
+// Named constraint of schema key
+type schemaCheck struct {
+	name string
+	fn   func(v *ConfigValue) error
+}
+
+// Creates empty schema
+func NewSchema() *Schema {
+	return &Schema{}
+}
+
+// Makes validation report every key of config, not described by schema (neither registered, nor being section
+// of registered key). Keys, starting with one of given prefixes (like "x-"), are ignored; prefixes are matched
+// against both key part and the whole key. Returns schema itself
+func (s *Schema) Strict(ignorePrefixes ...string) *Schema {
+	s.strict = true
+	s.ignore = ignorePrefixes
+	return s
+}
+
+// Adds rule, checking several keys at once (like MutuallyRequired() or LessOrEqual()). Rules are executed after
+// checks of keys; for schemas of slice elements (see SchemaKey.Each()) rule gets element as Config.
+// Problems, returned by rule as ValidationErrors or ValidationError, are merged into result of validation;
+// other errors are reported for the section, checked by schema. Returns schema itself
+func (s *Schema) Rule(rule func(c *Config) error) *Schema {
+	s.rules = append(s.rules, rule)
+	return s
+}
+
+// Registers key (see Config.Get() for key format) of given kind. Numbers match both Int and Float kinds,
+// if they can be converted without loss
+func (s *Schema) Key(key string, kind ValueKind) *SchemaKey {
+	k := &SchemaKey{key: key, kind: kind}
+	s.keys = append(s.keys, k)
+	return k
+}
+
+// Marks key as required: it must be set to non-null value (unless it has default value and defaults were applied)
+func (k *SchemaKey) Required() *SchemaKey {
+	k.required = true
+	return k
+}
+
+// Makes all problems of the key (including missing required value) warnings instead of errors: they are reported
+// by Schema.Check(), but not by Schema.Validate(). To have both errors and warnings for the same key, register it twice:
+//
+//	s.Key("pool.size", conf8n.Int).Required()
+//	s.Key("pool.size", conf8n.Int).Max(100).Warn()
+func (k *SchemaKey) Warn() *SchemaKey {
+	k.warn = true
+	return k
+}
+
+// Sets description of the key (used by Skeleton())
+func (k *SchemaKey) Desc(text string) *SchemaKey {
+	k.desc = text
+	return k
+}
+
+// Sets value, used by Schema.ApplyDefaults() if key is not set
+func (k *SchemaKey) Default(value interface{}) *SchemaKey {
+	k.def = value
+	return k
+}
+
+// Restricts value to one of given ones (numbers are compared regardless of their representation)
+func (k *SchemaKey) OneOf(values ...interface{}) *SchemaKey {
+	k.allowed = values
+	return k
+}
+
+// Restricts numeric value to range [min, max]
+func (k *SchemaKey) Range(min, max float64) *SchemaKey {
+	return k.Min(min).Max(max)
+}
+
+// Requires numeric value to be greater than or equal to min
+func (k *SchemaKey) Min(min float64) *SchemaKey {
+	return k.numberCheck("min", func(n float64) bool { return n >= min }, "less than %v", min)
+}
+
+// Requires numeric value to be less than or equal to max
+func (k *SchemaKey) Max(max float64) *SchemaKey {
+	return k.numberCheck("max", func(n float64) bool { return n <= max }, "greater than %v", max)
+}
+
+// Requires numeric value to be greater than min
+func (k *SchemaKey) ExclusiveMin(min float64) *SchemaKey {
+	return k.numberCheck("exclusiveMin", func(n float64) bool { return n > min }, "not greater than %v", min)
+}
+
+// Requires numeric value to be less than max
+func (k *SchemaKey) ExclusiveMax(max float64) *SchemaKey {
+	return k.numberCheck("exclusiveMax", func(n float64) bool { return n < max }, "not less than %v", max)
+}
+
+// Requires string value to have at least n characters
+func (k *SchemaKey) MinLen(n int) *SchemaKey {
+	return k.lengthCheck("minLen", true, func(l int) bool { return l >= n }, "at least", n)
+}
+
+// Requires string value to have at most n characters
+func (k *SchemaKey) MaxLen(n int) *SchemaKey {
+	return k.lengthCheck("maxLen", true, func(l int) bool { return l <= n }, "at most", n)
+}
+
+// Requires slice or map value to have at least n items
+func (k *SchemaKey) MinItems(n int) *SchemaKey {
+	return k.lengthCheck("minItems", false, func(l int) bool { return l >= n }, "at least", n)
+}
+
+// Requires slice or map value to have at most n items
+func (k *SchemaKey) MaxItems(n int) *SchemaKey {
+	return k.lengthCheck("maxItems", false, func(l int) bool { return l <= n }, "at most", n)
+}
+
+// Requires string value to match regular expression. Panics if expression can't be compiled
+func (k *SchemaKey) Pattern(expr string) *SchemaKey {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		panic(fmt.Sprintf("conf8n: invalid pattern of key '%s': %v", k.key, err))
+	}
+	k.checks = append(k.checks, schemaCheck{"pattern", func(v *ConfigValue) error {
+		if s, ok := v.v.(string); ok && !re.MatchString(s) {
+			return fmt.Errorf("Value %s doesn't match pattern '%s'", showValue(v.key, s), expr)
+		}
+		return nil
+	}})
+	return k
+}
+
+// Requires string value to have given format: "hostname", "email", "url" (or "uri"), "ipv4", "ipv6"
+// or "duration" (as accepted by time.ParseDuration()). Panics if format is unknown
+func (k *SchemaKey) Format(name string) *SchemaKey {
+	valid, found := formats[name]
+	if !found {
+		panic(fmt.Sprintf("conf8n: unknown format '%s' of key '%s'", name, k.key))
+	}
+	k.checks = append(k.checks, schemaCheck{"format", func(v *ConfigValue) error {
+		if s, ok := v.v.(string); ok && !valid(s) {
+			return fmt.Errorf("Value %s is not a valid %s", showValue(v.key, s), name)
+		}
+		return nil
+	}})
+	return k
+}
+
+// Adds check with validators, registered with RegisterValidator(). Panics if some of validators is not registered
+func (k *SchemaKey) Validate(names ...string) *SchemaKey {
+	for _, name := range names {
+		fn, found := lookupValidator(name)
+		if !found {
+			panic(fmt.Sprintf("conf8n: validator '%s' of key '%s' is not registered", name, k.key))
+		}
+		k.checks = append(k.checks, schemaCheck{name, fn})
+	}
+	return k
+}
+
+// Sets schema for every element of slice (or every value of map); elements must be maps
+func (k *SchemaKey) Each(schema *Schema) *SchemaKey {
+	k.each = schema
+	return k
+}
+
+func (k *SchemaKey) numberCheck(name string, ok func(n float64) bool, format string, limit float64) *SchemaKey {
+	k.checks = append(k.checks, schemaCheck{name, func(v *ConfigValue) error {
+		if n, isNumber := toNumber(v.v); isNumber && !ok(n) {
+			return fmt.Errorf("Value %s is "+format, showValue(v.key, v.v), limit)
+		}
+		return nil
+	}})
+	return k
+}
+
+// Adds check of length: count of characters of strings (if ofString is true) or count of items of slices and maps
+func (k *SchemaKey) lengthCheck(name string, ofString bool, ok func(l int) bool, bound string, limit int) *SchemaKey {
+	k.checks = append(k.checks, schemaCheck{name, func(v *ConfigValue) error {
+		l := -1
+		if s, isStr := v.v.(string); isStr && ofString {
+			l = utf8.RuneCountInString(s)
+		} else if a, isSlice := v.v.([]interface{}); isSlice && !ofString {
+			l = len(a)
+		} else if m := toStrMap(v.v); m != nil && !ofString {
+			l = len(m)
+		}
+		if l >= 0 && !ok(l) {
+			return fmt.Errorf("Value %s has length %d, expected %s %d", showValue(v.key, v.v), l, bound, limit)
+		}
+		return nil
+	}})
+	return k
+}
+
+// Checks config against schema. Reports all found errors at once; every problem contains the key.
+// Warnings (see SchemaKey.Warn()) are not reported, use Check() to get them
+func (s *Schema) Validate(c *Config) error {
+	return s.Check(c).Errors().result()
+}
+
+// Checks config against schema and returns all found problems (both errors and warnings), sorted by paths.
+// Use ValidationErrors.Errors() and Warnings() to tell them apart
+func (s *Schema) Check(c *Config) ValidationErrors {
+	var problems ValidationErrors
+	s.validate(c, "", &problems)
+	problems.result()
+	return problems
+}
+
+// Sets default values of schema for all missing keys (including keys of slice elements, described with Each())
+// and returns paths of keys, that got defaults. Keys, explicitly set to null, are not missing, so they are kept as is
+func (s *Schema) ApplyDefaults(c *Config) ([]string, error) {
+	var applied []string
+	err := s.applyDefaults(c, "", &applied)
+	return applied, err
+}
+
+func (s *Schema) applyDefaults(c *Config, prefix string, applied *[]string) error {
+	for _, k := range s.keys {
+		if !c.Has(k.key) && k.def != nil {
+			if err := c.Set(k.key, k.def); err != nil {
+				return err
+			}
+			*applied = append(*applied, joinPrefix(prefix, k.key, c.separator()))
+		}
+		if k.each == nil {
+			continue
+		}
+		v := c.Get(k.key)
+		for it := v.Iterate(); !it.Finished(); it.Next() {
+			section, err := it.Section()
+			if err != nil {
+				continue
+			}
+			elKey := it.Key()
+			if elKey == "" {
+				elKey = strconv.Itoa(it.Index())
+			}
+			elPrefix := joinPrefix(prefix, joinPrefix(k.key, escapeKeyChunk(elKey, c.separator()), c.separator()), c.separator())
+			if err := k.each.applyDefaults(section, elPrefix, applied); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Joins composite keys
+func joinPrefix(prefix, key, sep string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + sep + key
+}
+
+// Checks keys of schema (with given prefix) against root config, collecting problems
+func (s *Schema) validate(root *Config, prefix string, problems *ValidationErrors) {
+	for _, k := range s.keys {
+		key := k.key
+		if prefix != "" {
+			key = prefix + root.separator() + key
+		}
+		if keyword, err := k.check(root.Get(key)); err != nil {
+			problem := root.validationError(key, keyword, err)
+			if k.warn {
+				problem.Severity = SeverityWarning
+			}
+			*problems = append(*problems, problem)
+			if !k.warn {
+				continue
+			}
+		}
+		if k.each == nil {
+			continue
+		}
+		for it := root.Get(key).Iterate(); !it.Finished(); it.Next() {
+			elKey := joinKey(key, it.Key(), root.separator())
+			if it.Key() == "" {
+				elKey = joinKey(key, fmt.Sprint(it.Index()), root.separator())
+			}
+			if kindOf(it.Value().v) != Map {
+				*problems = append(*problems, root.validationError(elKey, "kind", kindErr(elKey, it.Value().v, Map)))
+				continue
+			}
+			k.each.validate(root, elKey, problems)
+		}
+	}
+	if len(s.rules) > 0 {
+		s.checkRules(root, prefix, problems)
+	}
+	if s.strict {
+		s.checkUnknown(root, prefix, problems)
+	}
+}
+
+// Runs rules of schema for section (with given prefix), adding prefix to paths of reported problems
+func (s *Schema) checkRules(root *Config, prefix string, problems *ValidationErrors) {
+	section := root
+	if prefix != "" {
+		var err error
+		if section, err = root.Get(prefix).MustConfig(); err != nil {
+			return
+		}
+	}
+	for _, rule := range s.rules {
+		err := rule(section)
+		if err == nil {
+			continue
+		}
+		var found ValidationErrors
+		switch e := err.(type) {
+		case ValidationErrors:
+			found = e
+		case ValidationError:
+			found = ValidationErrors{e}
+		default:
+			found = ValidationErrors{section.validationError("", "rule", err)}
+		}
+		for _, problem := range found {
+			if prefix != "" && problem.Path == "" {
+				problem.Path = prefix
+			} else if prefix != "" {
+				problem.Path = prefix + root.separator() + problem.Path
+			}
+			*problems = append(*problems, problem)
+		}
+	}
+}
+
+// Returns rule, requiring all given keys to be set, if some of them is set
+func MutuallyRequired(keys ...string) func(c *Config) error {
+	return func(c *Config) error {
+		var set, unset []string
+		for _, key := range keys {
+			if c.Get(key).IsSet() {
+				set = append(set, key)
+			} else {
+				unset = append(unset, key)
+			}
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		var problems ValidationErrors
+		for _, key := range unset {
+			problems = append(problems, c.validationError(key, "mutuallyRequired",
+				fmt.Errorf("Value is required, because '%s' is set", strings.Join(set, "', '"))))
+		}
+		return problems.result()
+	}
+}
+
+// Returns rule, requiring numeric value of keyA to be less than or equal to value of keyB (if both are set)
+func LessOrEqual(keyA, keyB string) func(c *Config) error {
+	return func(c *Config) error {
+		a, aSet := toNumber(c.Get(keyA).Raw())
+		b, bSet := toNumber(c.Get(keyB).Raw())
+		if aSet && bSet && a > b {
+			return c.validationError(keyA, "lessOrEqual", fmt.Errorf("Value %s is greater than value of '%s' (%s)", showValue(keyA, a), keyB, showValue(keyB, b)))
+		}
+		return nil
+	}
+}
+
+// Condition of schema, under which additional requirements are applied (see Schema.When())
+type Condition struct {
+	schema *Schema
+	desc   string
+	pred   func(c *Config) bool
+}
+
+// Starts conditional requirement, applied when value of the key is equal to given one
+// (numbers are compared regardless of their representation). Example:
+//
+//	s.When("tls.enabled", true).Require("tls.cert_file", "tls.key_file")
+//	s.When("storage.type", "s3").Require("storage.bucket")
+func (s *Schema) When(key string, equals interface{}) *Condition {
+	desc := fmt.Sprintf("'%s' is %v", key, equals)
+	if str, isStr := equals.(string); isStr {
+		desc = fmt.Sprintf("'%s' is '%s'", key, str)
+	}
+	return &Condition{
+		schema: s,
+		desc:   desc,
+		pred: func(c *Config) bool {
+			v := c.Get(key)
+			return v.IsSet() && jsonEqual(v.v, equals)
+		},
+	}
+}
+
+// Same as When(), but condition is given as predicate, called with the checked section
+func (s *Schema) WhenFunc(pred func(c *Config) bool) *Condition {
+	return &Condition{schema: s, desc: "condition is met", pred: pred}
+}
+
+// Requires given keys to be set (to non-null values), if condition is met. Returns schema of the condition
+func (cond *Condition) Require(keys ...string) *Schema {
+	return cond.schema.Rule(func(c *Config) error {
+		if !cond.pred(c) {
+			return nil
+		}
+		var problems ValidationErrors
+		for _, key := range keys {
+			if !c.Get(key).IsSet() {
+				problems = append(problems, c.validationError(key, "required",
+					fmt.Errorf("Value is required when %s, but not set", cond.desc)))
+			}
+		}
+		return problems.result()
+	})
+}
+
+// Reports keys of section (with given prefix), not described by schema
+func (s *Schema) checkUnknown(root *Config, prefix string, problems *ValidationErrors) {
+	sep := root.separator()
+	var section interface{} = root.tree()
+	if prefix != "" {
+		section = root.Get(prefix).v
+	}
+	known := make([][]string, len(s.keys))
+	for i, k := range s.keys {
+		known[i] = splitKey(k.key, sep)
+	}
+	walkTree(section, "", sep, func(path string, _ interface{}) error {
+		chunks := splitKey(path, sep)
+		isSection := false
+		for _, k := range known {
+			if chunksHavePrefix(k, chunks) {
+				if len(k) == len(chunks) {
+					return SkipSubtree
+				}
+				isSection = true
+			}
+		}
+		if isSection {
+			return nil
+		}
+		key := path
+		if prefix != "" {
+			key = prefix + sep + path
+		}
+		if !s.ignored(chunks[len(chunks)-1], key) {
+			*problems = append(*problems, root.validationError(key, "unknown", fmt.Errorf("Key is not described by schema")))
+		}
+		return SkipSubtree
+	})
+}
+
+func chunksHavePrefix(chunks, prefix []string) bool {
+	if len(chunks) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if chunks[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks, that key should not be reported as unknown
+func (s *Schema) ignored(key, path string) bool {
+	for _, prefix := range s.ignore {
+		if strings.HasPrefix(key, prefix) || strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks single value against rules of the key, returning failed rule and error
+func (k *SchemaKey) check(v *ConfigValue) (string, error) {
+	if !v.IsSet() {
+		if k.required {
+			return "required", fmt.Errorf("Value is required, but not set")
+		}
+		return "", nil
+	}
+	if !kindMatches(v.v, k.kind) {
+		return "kind", kindErr(v.key, v.v, k.kind)
+	}
+	if len(k.allowed) > 0 {
+		allowed := false
+		names := make([]string, len(k.allowed))
+		for i, a := range k.allowed {
+			allowed = allowed || looseEqual(v.v, a)
+			names[i] = fmt.Sprint(a)
+		}
+		if !allowed {
+			return "oneof", fmt.Errorf("Value %s is not one of: %s", showValue(v.key, v.v), strings.Join(names, ", "))
+		}
+	}
+	for _, check := range k.checks {
+		if err := check.fn(v); err != nil {
+			return check.name, err
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,227 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Returns problems as list of "path keyword" strings
+func problemList(problems ValidationErrors) []string {
+	var list []string
+	for _, p := range problems {
+		list = append(list, p.Path+" "+p.Keyword)
+	}
+	return list
+}
+
+func TestSchemaCheck(t *testing.T) {
+	hostSchema := func() *Schema {
+		s := NewSchema()
+		s.Key("host", String).Required()
+		s.Key("port", Int).Range(1, 65535)
+		return s
+	}
+	tests := []struct {
+		name   string
+		schema func() *Schema
+		tree   map[string]interface{}
+		want   []string
+	}{
+		{
+			name: "required",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("server.host", String).Required()
+				s.Key("server.port", Int).Required()
+				s.Key("server.name", String)
+				return s
+			},
+			tree: map[string]interface{}{"server": map[string]interface{}{"host": "localhost", "port": nil}},
+			want: []string{"server.port required"},
+		},
+		{
+			name: "kind",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("port", Int)
+				s.Key("ratio", Float)
+				s.Key("name", String)
+				return s
+			},
+			tree: map[string]interface{}{"port": 80.0, "ratio": 1, "name": 5},
+			want: []string{"name kind"},
+		},
+		{
+			name: "range",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("a", Int).Range(1, 10)
+				s.Key("b", Int).Range(1, 10)
+				s.Key("c", Int).Range(1, 10)
+				s.Key("d", Float).Range(1, 10)
+				s.Key("e", Int).Range(1, 10)
+				return s
+			},
+			tree: map[string]interface{}{"a": 1, "b": 10, "c": 0, "d": 10.5, "e": 5},
+			want: []string{"c min", "d max"},
+		},
+		{
+			name: "each element of slice",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("servers", Slice).Each(hostSchema())
+				return s
+			},
+			tree: map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 80},
+				map[string]interface{}{"port": 0},
+				"c",
+			}},
+			want: []string{"servers.1.host required", "servers.1.port min", "servers.2 kind"},
+		},
+		{
+			name: "each value of map",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("servers", Map).Each(hostSchema())
+				return s
+			},
+			tree: map[string]interface{}{"servers": map[string]interface{}{
+				"a": map[string]interface{}{"host": "a"},
+				"b": map[string]interface{}{"port": 70000},
+			}},
+			want: []string{"servers.b.host required", "servers.b.port max"},
+		},
+		{
+			name: "when",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.When("tls.enabled", true).Require("tls.cert", "tls.key")
+				s.When("storage.type", "s3").Require("storage.bucket")
+				s.When("workers", 4).Require("queue")
+				return s
+			},
+			tree: map[string]interface{}{
+				"tls":     map[string]interface{}{"enabled": true, "cert": "c"},
+				"storage": map[string]interface{}{"type": "fs"},
+				"workers": 4.0,
+			},
+			want: []string{"queue required", "tls.key required"},
+		},
+		{
+			name: "when inside each",
+			schema: func() *Schema {
+				el := NewSchema()
+				el.When("tls", true).Require("cert")
+				s := NewSchema()
+				s.Key("servers", Slice).Each(el)
+				return s
+			},
+			tree: map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"tls": true, "cert": "c"},
+				map[string]interface{}{"tls": true},
+				map[string]interface{}{"tls": false},
+			}},
+			want: []string{"servers.1.cert required"},
+		},
+		{
+			name: "mutually required",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Rule(MutuallyRequired("auth.user", "auth.password"))
+				s.Rule(MutuallyRequired("proxy.host", "proxy.port"))
+				s.Rule(MutuallyRequired("cache.size", "cache.ttl"))
+				return s
+			},
+			tree: map[string]interface{}{
+				"auth":  map[string]interface{}{"password": "secret"},
+				"proxy": map[string]interface{}{"host": "p", "port": 3128},
+			},
+			want: []string{"auth.user mutuallyRequired"},
+		},
+		{
+			name: "warnings",
+			schema: func() *Schema {
+				s := NewSchema()
+				s.Key("pool.size", Int).Required()
+				s.Key("pool.size", Int).Max(100).Warn()
+				s.Key("pool.name", String).Required().Warn()
+				return s
+			},
+			tree: map[string]interface{}{"pool": map[string]interface{}{"size": 200}},
+			want: []string{"pool.name required", "pool.size max"},
+		},
+	}
+	for _, test := range tests {
+		got := problemList(test.schema().Check(NewConfig(test.tree)))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSchemaValidateSkipsWarnings(t *testing.T) {
+	s := NewSchema()
+	s.Key("pool.size", Int).Max(100).Warn()
+	c := NewConfig(map[string]interface{}{"pool": map[string]interface{}{"size": 200}})
+	if err := s.Validate(c); err != nil {
+		t.Errorf("Warnings are reported by Validate(): %v", err)
+	}
+	s.Key("pool.size", Int).Max(150)
+	err := s.Validate(c)
+	problems, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("ValidationErrors expected, got %v", err)
+	}
+	if got := problemList(problems); !reflect.DeepEqual(got, []string{"pool.size max"}) {
+		t.Errorf("Unexpected problems: %v", got)
+	}
+}
+
+func TestSchemaApplyDefaults(t *testing.T) {
+	el := NewSchema()
+	el.Key("port", Int).Default(80)
+	el.Key("tls", Bool).Default(false)
+	s := NewSchema()
+	s.Key("timeout", String).Default("5s")
+	s.Key("name", String).Default("app")
+	s.Key("servers", Slice).Each(el)
+	s.Key("pools", Map).Each(el)
+	c := NewConfig(map[string]interface{}{
+		"name": nil,
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a"},
+			map[string]interface{}{"host": "b", "port": 8080, "tls": true},
+		},
+		"pools": map[string]interface{}{
+			"x": map[string]interface{}{"port": 81},
+		},
+	})
+	applied, err := s.ApplyDefaults(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantApplied := []string{"timeout", "servers.0.port", "servers.0.tls", "pools.x.tls"}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("Applied defaults: got %v, want %v", applied, wantApplied)
+	}
+	tests := map[string]interface{}{
+		"timeout":        "5s",
+		"name":           nil,
+		"servers.0.host": "a",
+		"servers.0.port": 80,
+		"servers.0.tls":  false,
+		"servers.1.port": 8080,
+		"servers.1.tls":  true,
+		"pools.x.port":   81,
+		"pools.x.tls":    false,
+	}
+	for key, want := range tests {
+		if got := c.Get(key).Raw(); got != want {
+			t.Errorf("%s: got %v, want %v", key, got, want)
+		}
+	}
+	if applied, _ := s.ApplyDefaults(c); len(applied) != 0 {
+		t.Errorf("Defaults are applied twice: %v", applied)
+	}
+}
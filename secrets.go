@@ -0,0 +1,120 @@
+package conf8n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Source of secret values, referenced in config as "${secret:ref}" (see Config.ResolveSecrets())
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Returned by SecretMap for unknown references; check for it with errors.Is()
+var ErrSecretNotFound = errors.New("Secret not found")
+
+// SecretResolver, backed by map of references to values. Useful in tests and for local development
+type SecretMap map[string]string
+
+// Returns value of the reference or ErrSecretNotFound
+func (m SecretMap) Resolve(_ context.Context, ref string) (string, error) {
+	if s, found := m[ref]; found {
+		return s, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+// Resolver and cache of resolved secrets, shared by config and its sub-configs
+type secretStore struct {
+	mu       sync.Mutex
+	resolver SecretResolver
+	cache    map[string]string
+}
+
+// Sets resolver of secret references (see ResolveSecrets()) and returns config itself.
+// Sub-configs, created after the call, share resolver and cache of resolved secrets with their parent
+func (c *Config) WithSecretResolver(r SecretResolver) *Config {
+	c.secrets = &secretStore{resolver: r, cache: make(map[string]string)}
+	return c
+}
+
+// Substitutes references to secrets, written as "${secret:ref}", in all string values of config with values,
+// given by resolver, set with WithSecretResolver(). Every reference is resolved once for the config lifetime;
+// "$${secret:...}" is kept as is, so ExpandEnv(), called after ResolveSecrets() and Resolve() (they use the same
+// ${...} syntax), turns it into literal "${secret:...}". For the same reason "$" in secret values is written as "$$",
+// so Resolve() and ExpandEnv() don't take parts of secrets for references, and ExpandEnv() restores them.
+// If some reference can't be resolved, error (naming the key and the reference, but not the value) is returned
+// and config stays unchanged
+func (c *Config) ResolveSecrets(ctx context.Context) error {
+	if c.secrets == nil {
+		return fmt.Errorf("Secret resolver is not set")
+	}
+	c.detach()
+	defer c.invalidate()
+	results := make(map[string]string)
+	if _, err := transformStrings(c.tree(), "", c.separator(), func(path, s string) (string, error) {
+		res, err := c.secrets.substitute(ctx, s)
+		if err != nil {
+			return "", c.keyError(path, err)
+		}
+		results[path] = res
+		return s, nil
+	}); err != nil {
+		return err
+	}
+	_, err := transformStrings(c.tree(), "", c.separator(), func(path, s string) (string, error) {
+		return results[path], nil
+	})
+	return err
+}
+
+// Replaces secret references in string
+func (st *secretStore) substitute(ctx context.Context, s string) (string, error) {
+	const open = "${secret:"
+	if !strings.Contains(s, open) {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, open)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			// escapes are removed by ExpandEnv()
+			b.WriteString(s[:i+len(open)])
+			s = s[i+len(open):]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("Unterminated secret reference")
+		}
+		b.WriteString(s[:i])
+		secret, err := st.resolve(ctx, s[i+len(open):i+end])
+		if err != nil {
+			return "", err
+		}
+		// escaped as "$$" to be kept by Resolve() and unescaped by ExpandEnv()
+		b.WriteString(strings.ReplaceAll(secret, "$", "$$"))
+		s = s[i+end+1:]
+	}
+}
+
+func (st *secretStore) resolve(ctx context.Context, ref string) (string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if secret, found := st.cache[ref]; found {
+		return secret, nil
+	}
+	secret, err := st.resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("Secret reference '%s' can't be resolved: %w", ref, err)
+	}
+	st.cache[ref] = secret
+	return secret, nil
+}
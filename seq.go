@@ -0,0 +1,56 @@
+//go:build go1.23
+
+package conf8n
+
+import "iter"
+
+// Returns iterator function over items of the value, usable in range statement:
+//
+//	for key, v := range config.Get("myMapValue").Seq() {
+//		fmt.Println(key, ":", v)
+//	}
+//
+// Order of items is the same, as for Iterate(); key is empty for slice elements (see SeqIdx())
+func (v ConfigValue) Seq() iter.Seq2[string, *ConfigValue] {
+	return func(yield func(string, *ConfigValue) bool) {
+		for it := v.Iterate(); !it.Finished(); it.Next() {
+			if !yield(it.Key(), it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// Same as Seq(), but yields indices of items instead of keys (for maps it is index of the key in sorted order)
+func (v ConfigValue) SeqIdx() iter.Seq2[int, *ConfigValue] {
+	return func(yield func(int, *ConfigValue) bool) {
+		for it := v.Iterate(); !it.Finished(); it.Next() {
+			if !yield(it.Index(), it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// Returns iterator function over top-level keys of config (in sorted order) and their values
+func (c *Config) Seq() iter.Seq2[string, *ConfigValue] {
+	return func(yield func(string, *ConfigValue) bool) {
+		c.Each(func(_ int, key string, v *ConfigValue) error {
+			if !yield(key, v) {
+				return SkipRest
+			}
+			return nil
+		})
+	}
+}
+
+// Same as SeqIdx(), but iterates in reverse order (see IterateReverse())
+func (v ConfigValue) SeqReverse() iter.Seq2[int, *ConfigValue] {
+	return func(yield func(int, *ConfigValue) bool) {
+		for it := v.IterateReverse(); !it.Finished(); it.Next() {
+			if !yield(it.Index(), it.Value()) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package conf8n
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Returns value by given key, or given default value if key was not set or its value has other type, than default.
+// Works the same way as Get(key).DefXXX(def), but without allocating intermediate ConfigValue
+func (c *Config) GetOr(key string, def interface{}) interface{} {
+	v, _ := c.lookup(key)
+	if v == nil || (def != nil && reflect.TypeOf(v) != reflect.TypeOf(def)) {
+		return def
+	}
+	return v
+}
+
+// Shortcut for Get(key).DefString(def)
+func (c *Config) GetStringOr(key string, def string) string {
+	v, _ := c.lookup(key)
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return def
+}
+
+// Shortcut for Get(key).DefInt(def)
+func (c *Config) GetIntOr(key string, def int) int {
+	v, _ := c.lookup(key)
+	if i, ok := v.(int); ok {
+		return i
+	}
+	return def
+}
+
+// Shortcut for Get(key).DefBool(def)
+func (c *Config) GetBoolOr(key string, def bool) bool {
+	v, _ := c.lookup(key)
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Shortcut for Get(key).DefFloat(def)
+func (c *Config) GetFloatOr(key string, def float64) float64 {
+	v, _ := c.lookup(key)
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return def
+}
+
+// Shortcut for Get(key).DefDuration(def)
+func (c *Config) GetDurationOr(key string, def time.Duration) time.Duration {
+	v, _ := c.lookup(key)
+	if d, ok := toDuration(v); ok {
+		return d
+	}
+	return def
+}
+
+// Shortcut for Get(key).String()
+func (c *Config) String(key string) string {
+	return c.GetStringOr(key, "")
+}
+
+// Shortcut for Get(key).Int()
+func (c *Config) Int(key string) int {
+	return c.GetIntOr(key, 0)
+}
+
+// Shortcut for Get(key).Float()
+func (c *Config) Float(key string) float64 {
+	return c.GetFloatOr(key, .0)
+}
+
+// Shortcut for Get(key).Bool()
+func (c *Config) Bool(key string) bool {
+	return c.GetBoolOr(key, false)
+}
+
+// Same as Get(key).MustString(), but error message will contain the key
+func (c *Config) MustString(key string) (string, error) {
+	v := c.GetV(key)
+	s, err := v.MustString()
+	return s, valueError(v, err)
+}
+
+// Same as Get(key).MustInt(), but error message will contain the key
+func (c *Config) MustInt(key string) (int, error) {
+	v := c.GetV(key)
+	i, err := v.MustInt()
+	return i, valueError(v, err)
+}
+
+// Same as Get(key).MustFloat(), but error message will contain the key
+func (c *Config) MustFloat(key string) (float64, error) {
+	v := c.GetV(key)
+	f, err := v.MustFloat()
+	return f, valueError(v, err)
+}
+
+// Same as Get(key).MustBool(), but error message will contain the key
+func (c *Config) MustBool(key string) (bool, error) {
+	v := c.GetV(key)
+	b, err := v.MustBool()
+	return b, valueError(v, err)
+}
+
+// Adds key to error message of value (errors for unset values and type errors already contain the key)
+func valueError(v ConfigValue, err error) error {
+	if err == nil || !v.IsSet() {
+		return err
+	}
+	if te, ok := err.(*TypeError); ok && te.Key != "" {
+		return err
+	}
+	return v.c.keyError(v.key, err)
+}
+
+// Adds key (and its location in source file, if known) to error message
+func (c *Config) keyError(key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if src, ok := c.SourceOf(key); ok {
+		return fmt.Errorf("Key '%s' (%s): %w", key, src, err)
+	}
+	return fmt.Errorf("Key '%s': %w", key, err)
+}
@@ -0,0 +1,38 @@
+package conf8n
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Reloads config with loader on every receiving of given signal (usually syscall.SIGHUP) and updates holder with it
+// (see Live.Update(), so validator of holder is applied). If loading or validation fails, error is reported through
+// holder (see Live.OnError()) and the previous config is kept (nil config without error is a failure too). Signals, received while config is being loaded, cause
+// single reload after the current one; config, loaded after cancellation of context, is dropped. Blocks until
+// context is canceled, so it is usually run in its own goroutine:
+//
+//	go conf8n.ReloadOnSignal(ctx, syscall.SIGHUP, func() (*conf8n.Config, error) {
+//		return conf8n.NewConfigFromFile("config.yaml")
+//	}, live)
+func ReloadOnSignal(ctx context.Context, sig os.Signal, loader func() (*Config, error), holder *Live) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+		c, err := loader()
+		if ctx.Err() != nil {
+			return
+		}
+		if c == nil && err == nil {
+			err = fmt.Errorf("Loader returned no config")
+		}
+		holder.reload("signal "+sig.String(), c, err)
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !windows
+// +build !windows
+
+package conf8n
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnSignalRejectsNilConfig(t *testing.T) {
+	initial := NewConfig(map[string]interface{}{"a": 1})
+	errs := make(chan error, 1)
+	holder := NewLive(initial).OnError(func(err error) { errs <- err })
+	// Default action of the signal terminates process, so it is caught until ReloadOnSignal() subscribes to it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loaded := make(chan struct{}, 1)
+	go ReloadOnSignal(ctx, syscall.SIGUSR1, func() (*Config, error) {
+		loaded <- struct{}{}
+		return nil, nil
+	}, holder)
+
+	deadline := time.After(5 * time.Second)
+	for sent := false; !sent; {
+		// Signal is resent until loader is called, as it may come before subscription of ReloadOnSignal()
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-loaded:
+			sent = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Loader was not called")
+		}
+	}
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Expected error")
+		}
+	case <-deadline:
+		t.Fatal("Error was not reported")
+	}
+	if holder.Load() != initial {
+		t.Error("Initial config is expected to be kept")
+	}
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	holder := NewLive(NewConfig(map[string]interface{}{"v": "initial"}))
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR2)
+	defer signal.Stop(guard)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ReloadOnSignal(ctx, syscall.SIGUSR2, func() (*Config, error) {
+			return NewConfig(map[string]interface{}{"v": "reloaded"}), nil
+		}, holder)
+		close(stopped)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for holder.Load().Get("v").String() != "reloaded" {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Config was not reloaded")
+		}
+	}
+	if h := holder.History(); h[len(h)-1].Source != "signal "+syscall.SIGUSR2.String() {
+		t.Errorf("Signal is expected to be recorded as source, got %q", h[len(h)-1].Source)
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-deadline:
+		t.Fatal("ReloadOnSignal() is not stopped by context")
+	}
+}
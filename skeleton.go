@@ -0,0 +1,288 @@
+package conf8n
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+// Node of example config tree
+type skeletonNode struct {
+	name     string
+	desc     string
+	required bool
+	value    interface{}
+	children []*skeletonNode
+	list     bool
+}
+
+// Generates example config document (JSON or YAML format) from config struct (or pointer to it) or from *Schema.
+// Struct fields are described by tags: `conf8n:"name,required"` (see ConfigValue.Decode()), `default:"value"`
+// and `desc:"description"`; schema keys - by SchemaKey.Required(), Default() and Desc().
+// In YAML document descriptions are given as comments, optional keys are commented out, required ones are not.
+// Keys without defaults get zero values of their types; slices of sections get single example element
+func Skeleton(v interface{}, format string) ([]byte, error) {
+	var nodes []*skeletonNode
+	if schema, ok := v.(*Schema); ok {
+		nodes = schemaSkeleton(schema)
+	} else {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("Skeleton source must be a struct or *Schema, got %T", v)
+		}
+		var err error
+		if nodes, err = structSkeleton(t, make(map[reflect.Type]bool)); err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case JSON:
+		return json.MarshalIndent(jsonCompatible(skeletonData(nodes)), "", "  ")
+	case YAML:
+		return []byte(strings.Join(skeletonYaml(nodes, "", false), "\n") + "\n"), nil
+	default:
+		return nil, fmt.Errorf("Unknown config format: '%s'", format)
+	}
+}
+
+// Types of structs, being described (visiting), are not descended again: recursive fields (like Children []Node
+// of Node) are given as empty sections or lists
+func structSkeleton(t reflect.Type, visiting map[reflect.Type]bool) ([]*skeletonNode, error) {
+	visiting[t] = true
+	defer delete(visiting, t)
+	var nodes []*skeletonNode
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("conf8n")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, opts = tag[:comma], tag[comma+1:]
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if visiting[ft] {
+				continue
+			}
+			embedded, err := structSkeleton(ft, visiting)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, embedded...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		node := &skeletonNode{
+			name:     name,
+			desc:     field.Tag.Get("desc"),
+			required: strings.Contains(","+opts+",", ",required,"),
+		}
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid constraints of field %s: %v", field.Name, err)
+		}
+		for _, r := range rules {
+			node.required = node.required || r.name == "required"
+		}
+		def, hasDef := field.Tag.Lookup("default")
+		switch {
+		case hasDef && ft.Kind() == reflect.String:
+			node.value = def
+		case hasDef:
+			if err := yaml.Unmarshal([]byte(def), &node.value); err != nil {
+				return nil, fmt.Errorf("Invalid default value of field %s: %v", field.Name, err)
+			}
+		case ft.Kind() == reflect.Struct && visiting[ft]:
+			node.value = map[string]interface{}{}
+		case ft.Kind() == reflect.Slice && visiting[ft.Elem()]:
+			node.value = []interface{}{}
+		case ft.Kind() == reflect.Struct && ft != timeType:
+			children, err := structSkeleton(ft, visiting)
+			if err != nil {
+				return nil, err
+			}
+			node.children = children
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			children, err := structSkeleton(ft.Elem(), visiting)
+			if err != nil {
+				return nil, err
+			}
+			node.children, node.list = children, true
+		default:
+			node.value = zeroExample(ft)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// Returns example value for type without default
+func zeroExample(t reflect.Type) interface{} {
+	switch {
+	case t == durationType:
+		return "0s"
+	case t == timeType:
+		return "2006-01-02T15:04:05Z"
+	case t.Kind() == reflect.Slice:
+		return []interface{}{}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{}
+	case t.Kind() == reflect.Interface:
+		return nil
+	}
+	return reflect.Zero(t).Interface()
+}
+
+func schemaSkeleton(s *Schema) []*skeletonNode {
+	root := &skeletonNode{}
+	for _, k := range s.keys {
+		node := root
+		for _, chunk := range splitKey(k.key, SEP) {
+			var child *skeletonNode
+			for _, c := range node.children {
+				if c.name == chunk {
+					child = c
+				}
+			}
+			if child == nil {
+				child = &skeletonNode{name: chunk}
+				node.children = append(node.children, child)
+			}
+			node = child
+		}
+		node.desc, node.required, node.value = k.desc, k.required, k.def
+		switch {
+		case k.each != nil:
+			node.children, node.list = schemaSkeleton(k.each), k.kind != Map
+			node.value = nil
+		case k.def == nil:
+			node.value = kindExample(k.kind)
+		}
+	}
+	return root.children
+}
+
+// Returns example value for schema key kind without default
+func kindExample(kind ValueKind) interface{} {
+	switch kind {
+	case Bool:
+		return false
+	case Int:
+		return 0
+	case Float:
+		return 0.0
+	case String:
+		return ""
+	case Slice:
+		return []interface{}{}
+	case Map:
+		return map[string]interface{}{}
+	}
+	return nil
+}
+
+// Returns true if node or some of its children is required
+func (n *skeletonNode) isRequired() bool {
+	if n.required {
+		return true
+	}
+	for _, c := range n.children {
+		if c.isRequired() {
+			return true
+		}
+	}
+	return false
+}
+
+func skeletonData(nodes []*skeletonNode) map[string]interface{} {
+	data := make(map[string]interface{}, len(nodes))
+	for _, n := range nodes {
+		switch {
+		case n.list:
+			data[n.name] = []interface{}{skeletonData(n.children)}
+		case n.children != nil:
+			data[n.name] = skeletonData(n.children)
+		default:
+			data[n.name] = n.value
+		}
+	}
+	return data
+}
+
+// Returns lines of YAML document; optional keys are commented out (unless they are inside of commented section)
+func skeletonYaml(nodes []*skeletonNode, indent string, commented bool) []string {
+	var lines []string
+	for _, n := range nodes {
+		if n.desc != "" {
+			lines = append(lines, indent+"# "+n.desc)
+		}
+		optional := !commented && !n.isRequired()
+		var block []string
+		switch {
+		case n.list:
+			block = append(block, indent+n.name+":")
+			el := skeletonYaml(n.children, indent+"    ", commented || optional)
+			if len(el) > 0 {
+				el[0] = indent + "  - " + el[0][len(indent)+4:]
+			}
+			block = append(block, el...)
+		case n.children != nil:
+			block = append(block, indent+n.name+":")
+			block = append(block, skeletonYaml(n.children, indent+"  ", commented || optional)...)
+		default:
+			block = append(block, indent+n.name+": "+yamlScalar(n.value))
+		}
+		if optional {
+			for i, line := range block {
+				block[i] = indent + "# " + line[len(indent):]
+			}
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+// Formats value for single line of YAML document (maps and slices are given in flow style)
+func yamlScalar(value interface{}) string {
+	if k := kindOf(value); k == Map || k == Slice {
+		if out, err := json.Marshal(jsonCompatible(value)); err == nil {
+			return string(out)
+		}
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Converts maps with non-string keys (as yaml decoder gives them) to string-keyed ones
+func jsonCompatible(value interface{}) interface{} {
+	if m := toStrMap(value); m != nil {
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[k] = jsonCompatible(v)
+		}
+		return res
+	}
+	if a, ok := value.([]interface{}); ok {
+		res := make([]interface{}, len(a))
+		for i, v := range a {
+			res[i] = jsonCompatible(v)
+		}
+		return res
+	}
+	return value
+}
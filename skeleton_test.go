@@ -0,0 +1,94 @@
+package conf8n
+
+import (
+	"strings"
+	"testing"
+)
+
+type skeletonTreeNode struct {
+	Name     string             `conf8n:"name,required" desc:"Node name"`
+	Children []skeletonTreeNode `conf8n:"children"`
+	Parent   *skeletonTreeNode  `conf8n:"parent"`
+}
+
+func TestSkeleton(t *testing.T) {
+	type server struct {
+		Host string `conf8n:"host,required"`
+		Port int    `conf8n:"port" default:"8080"`
+	}
+	type app struct {
+		Name    string   `conf8n:"name" validate:"required" desc:"Application name"`
+		Debug   bool     `conf8n:"debug"`
+		Servers []server `conf8n:"servers"`
+	}
+	schema := NewSchema()
+	schema.Key("db.host", String).Required().Desc("Database host")
+	schema.Key("db.port", Int).Default(5432)
+	tests := []struct {
+		name   string
+		source interface{}
+		format string
+		want   string
+	}{
+		{"struct", &app{}, YAML, `# Application name
+name: ""
+# debug: false
+servers:
+  - host: ""
+    # port: 8080
+`},
+		{"struct", app{}, JSON, `{
+  "debug": false,
+  "name": "",
+  "servers": [
+    {
+      "host": "",
+      "port": 8080
+    }
+  ]
+}`},
+		{"recursive struct", skeletonTreeNode{}, YAML, `# Node name
+name: ""
+# children: []
+# parent: {}
+`},
+		{"recursive struct", skeletonTreeNode{}, JSON, `{
+  "children": [],
+  "name": "",
+  "parent": {}
+}`},
+		{"schema", schema, YAML, `db:
+  # Database host
+  host: ""
+  # port: 5432
+`},
+	}
+	for _, tt := range tests {
+		out, err := Skeleton(tt.source, tt.format)
+		if err != nil {
+			t.Errorf("%s (%s): %v", tt.name, tt.format, err)
+		} else if string(out) != tt.want {
+			t.Errorf("%s (%s): got\n%s\nwant\n%s", tt.name, tt.format, out, tt.want)
+		}
+	}
+}
+
+func TestSkeletonErrors(t *testing.T) {
+	type invalid struct {
+		Port int `default:"["`
+	}
+	tests := []struct {
+		source interface{}
+		format string
+		err    string
+	}{
+		{42, YAML, "must be a struct"},
+		{skeletonTreeNode{}, "xml", "Unknown config format"},
+		{invalid{}, YAML, "Invalid default value of field Port"},
+	}
+	for _, tt := range tests {
+		if _, err := Skeleton(tt.source, tt.format); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%T (%s): expected error %q, got %v", tt.source, tt.format, tt.err, err)
+		}
+	}
+}
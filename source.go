@@ -0,0 +1,145 @@
+package conf8n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strconv"
+)
+
+// Location of the key in config source
+type Source struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// Returns location in "file:line:column" format (file is omitted, if unknown)
+func (s Source) String() string {
+	if s.File == "" {
+		return fmt.Sprintf("line %d, column %d", s.Line, s.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
+
+// Returns location of the key in source data (see Get() for key format). Locations are known
+// for configs, loaded from YAML or JSON data (and their sub-configs)
+func (c *Config) SourceOf(key string) (Source, bool) {
+	if c == nil || c.sources == nil {
+		return Source{}, false
+	}
+	chunks := append(append([]string{}, c.path...), c.keyChunks(key)...)
+	src, ok := c.sources[joinKeyChunks(chunks, SEP)]
+	return src, ok
+}
+
+// Sets file name for all locations of config
+func (c *Config) setSourceFile(filename string) {
+	for k, src := range c.sources {
+		src.File = filename
+		c.sources[k] = src
+	}
+}
+
+// Returns locations and order of keys of YAML document. Both are empty, if document can't be parsed by yaml.v3
+// (it is stricter than yaml.v2, that decodes values)
+func yamlLayout(data []byte) (map[string]Source, map[string][]string) {
+	sources, order := make(map[string]Source), make(map[string][]string)
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err == nil {
+		yamlPositions(&root, nil, sources)
+		yamlKeyOrder(&root, nil, order)
+	}
+	return sources, order
+}
+
+// Collects locations of all keys and slice elements of YAML document
+func yamlPositions(node *yaml.Node, path []string, sources map[string]Source) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlPositions(n, path, sources)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag == "!!merge" {
+				yamlPositions(value, path, sources)
+				continue
+			}
+			childPath := append(append([]string{}, path...), key.Value)
+			sources[joinKeyChunks(childPath, SEP)] = Source{Line: key.Line, Column: key.Column}
+			yamlPositions(value, childPath, sources)
+		}
+	case yaml.SequenceNode:
+		for i, el := range node.Content {
+			childPath := append(append([]string{}, path...), strconv.Itoa(i))
+			sources[joinKeyChunks(childPath, SEP)] = Source{Line: el.Line, Column: el.Column}
+			yamlPositions(el, childPath, sources)
+		}
+	}
+}
+
+// Collects locations of all keys and array elements of JSON document
+func jsonPositions(data []byte) map[string]Source {
+	type frame struct {
+		path      []string
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+	lineStarts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	position := func(offset int) Source {
+		for offset < len(data) && (data[offset] == ' ' || data[offset] == '\t' || data[offset] == '\r' ||
+			data[offset] == '\n' || data[offset] == ',' || data[offset] == ':') {
+			offset++
+		}
+		line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset })
+		return Source{Line: line, Column: offset - lineStarts[line-1] + 1}
+	}
+	sources := make(map[string]Source)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+	for {
+		start := position(int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			return sources
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		var path []string
+		switch {
+		case top == nil:
+		case top.object && top.expectKey:
+			top.key, _ = tok.(string)
+			top.expectKey = false
+			sources[joinKeyChunks(append(append([]string{}, top.path...), top.key), SEP)] = start
+			continue
+		case top.object:
+			path = append(append([]string{}, top.path...), top.key)
+			top.expectKey = true
+		default:
+			path = append(append([]string{}, top.path...), strconv.Itoa(top.index))
+			sources[joinKeyChunks(path, SEP)] = start
+			top.index++
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, &frame{path: path, object: delim == '{', expectKey: delim == '{'})
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package conf8n
+
+import "context"
+
+// Item of slice or map, sent by Chan()
+type Entry struct {
+	Index int
+	Key   string
+	Value *ConfigValue
+}
+
+// Sends items of slice or map (in the same order, as Iterate() does) to returned channel from separate goroutine.
+// Channel is closed, when all items are sent or ctx is canceled. Channel is unbuffered, unless buffer size is given
+func (v ConfigValue) Chan(ctx context.Context, buffer ...int) <-chan Entry {
+	size := 0
+	if len(buffer) > 0 {
+		size = buffer[0]
+	}
+	ch := make(chan Entry, size)
+	it := v.Iterate()
+	go func() {
+		defer close(ch)
+		for ; !it.Finished(); it.Next() {
+			select {
+			case ch <- Entry{Index: it.Index(), Key: it.Key(), Value: it.Value()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,93 @@
+package conf8n
+
+import "sync"
+
+// Subscription to changes of config section in Live holder (see Live.Subscribe())
+type Subscription struct {
+	l       *Live
+	key     string
+	fn      func(old, new *ConfigValue)
+	mu      sync.Mutex
+	pending []configChange
+	wake    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Configs before and after update of holder
+type configChange struct {
+	prev, next *Config
+}
+
+// Subscribes to changes of value by given key (see Get() for key format; empty key means the whole config). After
+// every update of holder, values by the key in previous and new configs are compared, and, if they differ, fn is
+// called with both of them (unset value stands for missing key). Calls are made sequentially, in order of updates,
+// from goroutine of the subscription, so slow callbacks don't delay updates and other subscribers
+func (l *Live) Subscribe(key string, fn func(old, new *ConfigValue)) *Subscription {
+	s := &Subscription{l: l, key: key, fn: fn, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	l.mu.Lock()
+	l.subs = append(l.subs, s)
+	l.mu.Unlock()
+	go s.run()
+	return s
+}
+
+// Cancels subscription. Notifications, that are not delivered yet, are dropped. Can be called from callback
+func (s *Subscription) Unsubscribe() {
+	s.l.mu.Lock()
+	for i, sub := range s.l.subs {
+		if sub == s {
+			s.l.subs = append(s.l.subs[:i], s.l.subs[i+1:]...)
+			break
+		}
+	}
+	s.l.mu.Unlock()
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *Subscription) enqueue(prev, next *Config) {
+	s.mu.Lock()
+	s.pending = append(s.pending, configChange{prev: prev, next: next})
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+		s.mu.Lock()
+		changes := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		for _, change := range changes {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			prev, next := valueOf(change.prev, s.key), valueOf(change.next, s.key)
+			var diff []string
+			diffTrees(prev.v, next.v, "", SEP, &diff)
+			if len(diff) > 0 {
+				s.fn(prev, next)
+			}
+		}
+	}
+}
+
+// Returns value by key of config; for nil config value is unset
+func valueOf(c *Config, key string) *ConfigValue {
+	if c == nil {
+		return &ConfigValue{c: NewConfig(nil), key: key}
+	}
+	return c.Get(key)
+}
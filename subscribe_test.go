@@ -0,0 +1,114 @@
+package conf8n
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Change of subscribed value
+type valueChange struct {
+	old, new *ConfigValue
+}
+
+func nextChange(t *testing.T, changes chan valueChange) valueChange {
+	select {
+	case ch := <-changes:
+		return ch
+	case <-time.After(5 * time.Second):
+		t.Fatal("Subscriber was not called")
+	}
+	return valueChange{}
+}
+
+func TestSubscribe(t *testing.T) {
+	l := NewLive(NewConfig(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "a", "port": 1},
+		"other": 1,
+	}))
+	changes := make(chan valueChange, 10)
+	s := l.Subscribe("db", func(old, new *ConfigValue) {
+		changes <- valueChange{old, new}
+	})
+	defer s.Unsubscribe()
+	updates := []map[string]interface{}{
+		// change of other key is not reported
+		{"db": map[string]interface{}{"host": "a", "port": 1}, "other": 2},
+		{"db": map[string]interface{}{"host": "b", "port": 1}, "other": 2},
+		{"other": 2},
+		{"db": map[string]interface{}{"host": "c"}},
+	}
+	for _, data := range updates {
+		l.Store(NewConfig(data))
+	}
+	steps := []struct {
+		oldHost, newHost string
+		oldSet, newSet   bool
+	}{
+		{"a", "b", true, true},
+		{"b", "", true, false},
+		{"", "c", false, true},
+	}
+	for i, step := range steps {
+		ch := nextChange(t, changes)
+		if ch.old.IsSet() != step.oldSet || ch.new.IsSet() != step.newSet {
+			t.Errorf("Change %d: got set %v -> %v, want %v -> %v", i, ch.old.IsSet(), ch.new.IsSet(), step.oldSet, step.newSet)
+		}
+		if got := ch.old.Config().Get("host").String(); got != step.oldHost {
+			t.Errorf("Change %d: old host %q, want %q", i, got, step.oldHost)
+		}
+		if got := ch.new.Config().Get("host").String(); got != step.newHost {
+			t.Errorf("Change %d: new host %q, want %q", i, got, step.newHost)
+		}
+	}
+	select {
+	case ch := <-changes:
+		t.Errorf("Unexpected change %v -> %v", ch.old, ch.new)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeToWholeConfig(t *testing.T) {
+	l := NewLive(nil)
+	changes := make(chan valueChange, 10)
+	s := l.Subscribe("", func(old, new *ConfigValue) {
+		changes <- valueChange{old, new}
+	})
+	defer s.Unsubscribe()
+	l.Store(NewConfig(map[string]interface{}{"v": 1}))
+	ch := nextChange(t, changes)
+	if ch.old.IsSet() || ch.new.Config().Get("v").Int() != 1 {
+		t.Errorf("Got %v -> %v", ch.old.Raw(), ch.new.Raw())
+	}
+	// rejected update is not reported
+	l.WithValidator(func(*Config) error { return errors.New("rejected") })
+	_ = l.Update(NewConfig(map[string]interface{}{"v": 2}))
+	select {
+	case ch := <-changes:
+		t.Errorf("Rejected update is reported: %v -> %v", ch.old.Raw(), ch.new.Raw())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	l := NewLive(NewConfig(map[string]interface{}{"v": 0}))
+	changes := make(chan valueChange, 10)
+	var s *Subscription
+	s = l.Subscribe("v", func(old, new *ConfigValue) {
+		changes <- valueChange{old, new}
+		// unsubscribing from callback must not deadlock
+		s.Unsubscribe()
+	})
+	l.Store(NewConfig(map[string]interface{}{"v": 1}))
+	if ch := nextChange(t, changes); ch.new.Int() != 1 {
+		t.Errorf("Got %v", ch.new.Raw())
+	}
+	l.Store(NewConfig(map[string]interface{}{"v": 2}))
+	select {
+	case ch := <-changes:
+		t.Errorf("Change after Unsubscribe() is reported: %v", ch.new.Raw())
+	case <-time.After(50 * time.Millisecond):
+	}
+	// repeated call does nothing
+	s.Unsubscribe()
+}
@@ -0,0 +1,65 @@
+package conf8n
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Enables access tracking mode for config (and its sub-configs, created after the call) and returns config itself.
+// In this mode every successful lookup (Get(), Has() and others) records the key, so unused keys could be
+// reported later (see UnusedKeys()). Reading a section to descend into it does not count as access of its keys
+func (c *Config) TrackAccess() *Config {
+	if c.accessed == nil {
+		c.accessed = new(sync.Map)
+	}
+	return c
+}
+
+// Returns sorted list of keys (relative to the root config), that were successfully read in access tracking mode
+func (c *Config) AccessedKeys() []string {
+	if c.accessed == nil {
+		return nil
+	}
+	var keys []string
+	c.accessed.Range(func(k, _ interface{}) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns sorted list of leaf keys (as returned by AllKeysIndexed()), that were never read in access tracking mode.
+// Leaf counts as read if it was read itself or as a part of slice (or other non-section value) it belongs to
+func (c *Config) UnusedKeys() []string {
+	if c.accessed == nil {
+		return nil
+	}
+	var unused []string
+	for _, key := range c.AllKeysIndexed() {
+		full := c.fullKey(key)
+		used := false
+		for prefix := full; ; {
+			if _, ok := c.accessed.Load(prefix); ok {
+				used = true
+				break
+			}
+			i := strings.LastIndex(prefix, SEP)
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+		if !used {
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
+
+func (c *Config) recordAccess(key string, v interface{}) {
+	if toStrMap(v) == nil {
+		c.accessed.Store(c.fullKey(key), true)
+	}
+}
@@ -1,30 +1,639 @@
 package conf8n
 
-func getValueWithCompositeKey(m map[string]interface{}, keyChunks []string, current int) interface{} {
-	data, _ := m[keyChunks[current]]
-	if current == len(keyChunks)-1 {
-		return data
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules of composite keys interpretation
+type keyFormat struct {
+	sep      string
+	foldCase bool
+}
+
+// Looks up value by key, split to chunks. Descends into maps by keys and into slices by numeric indices.
+// Returned error describes, where and why traversal stopped
+func getValueWithCompositeKey(root interface{}, keyChunks []string, f keyFormat) (interface{}, error) {
+	node := root
+	for i, chunk := range keyChunks {
+		var err error
+		node, err = descend(node, chunk, i, func() string {
+			return joinKeyChunks(keyChunks[:i], f.sep)
+		}, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// Same as getValueWithCompositeKey(), but key is split by separator while traversing, without allocations.
+// Key must not contain escaped separators (see splitKey())
+func getValueWithKey(root interface{}, key string, f keyFormat) (interface{}, error) {
+	for i, rest := 0, key; ; i++ {
+		end := strings.Index(rest, f.sep)
+		if end == 0 || end < 0 && rest == "" {
+			return nil, wrapf(ErrNotSet, "empty path segment at position %d", i)
+		}
+		if end < 0 {
+			break
+		}
+		rest = rest[end+len(f.sep):]
 	}
-	if data := toStrMap(data); data != nil {
-		return getValueWithCompositeKey(data, keyChunks, current+1)
+	node := root
+	for i, start := 0, 0; ; i++ {
+		end := strings.Index(key[start:], f.sep)
+		chunk := key[start:]
+		if end >= 0 {
+			chunk = key[start : start+end]
+		}
+		var err error
+		node, err = descend(node, chunk, i, func() string {
+			return key[:start-len(f.sep)]
+		}, f)
+		if err != nil {
+			return nil, err
+		}
+		if end < 0 {
+			return node, nil
+		}
+		start += end + len(f.sep)
+	}
+}
+
+// Returns child of node by i-th part of key. Path to node (for error message) is given by parent function
+func descend(node interface{}, chunk string, i int, parent func() string, f keyFormat) (interface{}, error) {
+	if a, ok := node.([]interface{}); ok {
+		idx, err := strconv.Atoi(chunk)
+		if err != nil {
+			return nil, wrapf(ErrNotSet, "'%s' is a slice, '%s' is not an index", parent(), chunk)
+		}
+		pos := idx
+		if pos < 0 {
+			pos += len(a)
+		}
+		if pos < 0 || pos >= len(a) {
+			return nil, wrapf(ErrIndexOutOfRange, "index %d out of range (len %d) at '%s'", idx, len(a), parent())
+		}
+		return a[pos], nil
+	}
+	_, v, found, isMap := nodeLookup(node, chunk, f.foldCase)
+	if !isMap {
+		return nil, wrapf(ErrNotSet, "'%s' is a %s (not a map), cannot descend to '%s'", parent(), kindOf(node), chunk)
+	}
+	if !found && i == 0 {
+		return nil, wrapf(ErrNotSet, "'%s' is not set", chunk)
+	}
+	if !found {
+		return nil, wrapf(ErrNotSet, "'%s' found, but has no key '%s'", parent(), chunk)
+	}
+	return v, nil
+}
+
+// Reports error, if some of key parts is empty (as in "a..b" or ".a" keys)
+func checkKeyChunks(keyChunks []string) error {
+	for i, chunk := range keyChunks {
+		if chunk == "" {
+			return wrapf(ErrNotSet, "empty path segment at position %d", i)
+		}
 	}
 	return nil
 }
 
+func setValueWithCompositeKey(m map[string]interface{}, keyChunks []string, f keyFormat, value interface{}) error {
+	last := len(keyChunks) - 1
+	for i, chunk := range keyChunks[:last] {
+		chunk, next, found := mapLookup(m, chunk, f.foldCase)
+		if !found || next == nil {
+			section := make(map[string]interface{})
+			m[chunk] = section
+			m = section
+			continue
+		}
+		section := toStrMap(next)
+		if section == nil {
+			return wrapf(ErrWrongType, "Key '%s' is a %s, not a section", joinKeyChunks(keyChunks[:i+1], f.sep), kindOf(next))
+		}
+		if _, isStrMap := next.(map[string]interface{}); !isStrMap {
+			m[chunk] = section
+		}
+		m = section
+	}
+	key, _, _ := mapLookup(m, keyChunks[last], f.foldCase)
+	m[key] = value
+	return nil
+}
+
+// Removes value by key, split to chunks, from its parent section (of any map kind). Returns false if not found
+func deleteWithCompositeKey(root interface{}, keyChunks []string, f keyFormat) bool {
+	last := len(keyChunks) - 1
+	parent, err := getValueWithCompositeKey(root, keyChunks[:last], f)
+	if err != nil {
+		return false
+	}
+	switch m := parent.(type) {
+	case map[string]interface{}:
+		key, _, found := mapLookup(m, keyChunks[last], f.foldCase)
+		delete(m, key)
+		return found
+	case map[interface{}]interface{}:
+		key, _, found := mapLookup(toStrMap(m), keyChunks[last], f.foldCase)
+		if _, isStr := m[key]; isStr {
+			delete(m, key)
+			return found
+		}
+		for k := range m {
+			if s, ok := stringifyKey(k); ok && s == key {
+				delete(m, k)
+			}
+		}
+		return found
+	}
+	return false
+}
+
+// Returns deep copy of tree: maps (of both kinds) and slices are copied, other values are shared.
+// Nodes, nested deeper than MaxDepth, are shared too (ErrMaxDepthExceeded is returned with the copy)
+func deepCopy(value interface{}) (interface{}, error) {
+	return deepCopyDepth(value, nil)
+}
+
+// Key parts of value are used for error message; their count is depth of value
+func deepCopyDepth(value interface{}, chunks []string) (interface{}, error) {
+	var err, childErr error
+	switch node := value.(type) {
+	case map[string]interface{}:
+		if len(node) > 0 && len(chunks) >= MaxDepth {
+			return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+		}
+		cp := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			if cp[k], childErr = deepCopyDepth(v, append(chunks, k)); err == nil {
+				err = childErr
+			}
+		}
+		return cp, err
+	case map[interface{}]interface{}:
+		if len(node) > 0 && len(chunks) >= MaxDepth {
+			return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+		}
+		cp := make(map[interface{}]interface{}, len(node))
+		for k, v := range node {
+			if cp[k], childErr = deepCopyDepth(v, append(chunks, fmt.Sprint(k))); err == nil {
+				err = childErr
+			}
+		}
+		return cp, err
+	case []interface{}:
+		if len(node) > 0 && len(chunks) >= MaxDepth {
+			return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+		}
+		cp := make([]interface{}, len(node))
+		for i, v := range node {
+			if cp[i], childErr = deepCopyDepth(v, append(chunks, strconv.Itoa(i))); err == nil {
+				err = childErr
+			}
+		}
+		return cp, err
+	}
+	return value, nil
+}
+
+// Returns deep copy of dst with src merged into it: maps are merged recursively, other values of src
+// (including slices and nulls) replace values of dst. Sections, nested deeper than MaxDepth, are not
+// merged and copied (see deepCopy())
+func mergeTrees(dst, src interface{}) (interface{}, error) {
+	return mergeTreesDepth(dst, src, nil)
+}
+
+func mergeTreesDepth(dst, src interface{}, chunks []string) (interface{}, error) {
+	md, ms := toStrMap(dst), toStrMap(src)
+	if md == nil || ms == nil {
+		return deepCopyDepth(src, chunks)
+	}
+	if len(md) > 0 && len(ms) > 0 && len(chunks) >= MaxDepth {
+		return src, maxDepthErr(joinKeyChunks(chunks, SEP))
+	}
+	var err, childErr error
+	merged := make(map[string]interface{}, len(md)+len(ms))
+	for k, v := range md {
+		if _, replaced := ms[k]; !replaced {
+			if merged[k], childErr = deepCopyDepth(v, append(chunks, k)); err == nil {
+				err = childErr
+			}
+		}
+	}
+	for k, v := range ms {
+		if merged[k], childErr = mergeTreesDepth(md[k], v, append(chunks, k)); err == nil {
+			err = childErr
+		}
+	}
+	return merged, err
+}
+
+// Looks up map value by key. If foldCase is true and there is no exact match, key is matched case-insensitively;
+// if several keys match, the first of them in sorted order wins. Returns actual key of the map (or given key,
+// if it was not found), value and flag, reporting was it found
+func mapLookup(m map[string]interface{}, key string, foldCase bool) (string, interface{}, bool) {
+	if v, found := m[key]; found || !foldCase {
+		return key, v, found
+	}
+	for _, k := range mapSortedKeys(m) {
+		if strings.EqualFold(k, key) {
+			return k, m[k], true
+		}
+	}
+	return key, nil, false
+}
+
+// Same as mapLookup(), but node may be a map of any supported type; it is not converted to string-keyed one.
+// Keys of other types than strings match by their string form (string keys take precedence, as in toStrMapE()).
+// The last result reports, if node is a map
+func nodeLookup(node interface{}, key string, foldCase bool) (string, interface{}, bool, bool) {
+	if m, ok := node.(map[string]interface{}); ok {
+		literal, v, found := mapLookup(m, key, foldCase)
+		return literal, v, found, true
+	}
+	im, ok := node.(map[interface{}]interface{})
+	if !ok {
+		return key, nil, false, false
+	}
+	if v, found := im[key]; found {
+		return key, v, true, true
+	}
+	if foldCase {
+		literal, v, found := mapLookup(toStrMap(im), key, foldCase)
+		return literal, v, found, true
+	}
+	if !stringifiable(key) {
+		// no need to scan the whole map
+		return key, nil, false, true
+	}
+	for k, v := range im {
+		if _, isStr := k.(string); isStr {
+			continue
+		}
+		if asStr, ok := stringifyKey(k); ok && asStr == key {
+			return key, v, true, true
+		}
+	}
+	return key, nil, false, true
+}
+
 func toStrMap(value interface{}) map[string]interface{} {
+	m, _ := toStrMapE(value)
+	return m
+}
+
+// Converts (in place, where possible) maps of tree to string-keyed ones, so lookups don't convert them again
+// and again. Maps with keys, that can't be stringified, are kept as is (see toStrMapE()). Nodes, nested deeper
+// than MaxDepth, are not converted (ErrMaxDepthExceeded is returned)
+func normalizeTree(value interface{}) (interface{}, error) {
+	return normalizeTreeDepth(value, nil)
+}
+
+func normalizeTreeDepth(value interface{}, chunks []string) (interface{}, error) {
+	var err error
+	switch node := value.(type) {
+	case map[string]interface{}:
+		if len(node) > 0 && len(chunks) >= MaxDepth {
+			return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+		}
+		for k, v := range node {
+			if node[k], err = normalizeTreeDepth(v, append(chunks, k)); err != nil {
+				return value, err
+			}
+		}
+	case map[interface{}]interface{}:
+		m, skipped := toStrMapE(node)
+		if len(skipped) > 0 {
+			if len(chunks) >= MaxDepth {
+				return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+			}
+			for k, v := range node {
+				if node[k], err = normalizeTreeDepth(v, append(chunks, fmt.Sprint(k))); err != nil {
+					return value, err
+				}
+			}
+			return node, nil
+		}
+		return normalizeTreeDepth(m, chunks)
+	case []interface{}:
+		if len(node) > 0 && len(chunks) >= MaxDepth {
+			return value, maxDepthErr(joinKeyChunks(chunks, SEP))
+		}
+		for i, v := range node {
+			if node[i], err = normalizeTreeDepth(v, append(chunks, strconv.Itoa(i))); err != nil {
+				return value, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// Converts map to string-keyed one. Integer, float and bool keys are stringified (80 -> "80");
+// keys, that can't be stringified, are skipped and returned as second result.
+// String keys win over stringified ones, if they collide. Returns nil if value is not a map
+func toStrMapE(value interface{}) (map[string]interface{}, []interface{}) {
 	if alreadyStrMap, ok := value.(map[string]interface{}); ok {
-		return alreadyStrMap
+		return alreadyStrMap, nil
+	}
+	asIMap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil
 	}
-	if asIMap, ok := value.(map[interface{}]interface{}); ok {
-		strMap := make(map[string]interface{}, len(asIMap))
-		for k, v := range asIMap {
-			if asStr, ok := k.(string); ok {
-				strMap[asStr] = v
-			} else {
-				return nil
+	strMap := make(map[string]interface{}, len(asIMap))
+	var skipped []interface{}
+	for k, v := range asIMap {
+		if _, isStr := k.(string); isStr {
+			continue
+		}
+		if asStr, ok := stringifyKey(k); ok {
+			strMap[asStr] = v
+		} else {
+			skipped = append(skipped, k)
+		}
+	}
+	for k, v := range asIMap {
+		if asStr, isStr := k.(string); isStr {
+			strMap[asStr] = v
+		}
+	}
+	return strMap, skipped
+}
+
+func stringifyKey(key interface{}) (string, bool) {
+	switch k := key.(type) {
+	case string:
+		return k, true
+	case int:
+		return strconv.Itoa(k), true
+	case int64:
+		return strconv.FormatInt(k, 10), true
+	case uint64:
+		return strconv.FormatUint(k, 10), true
+	case float64:
+		return strconv.FormatFloat(k, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(k), true
+	}
+	return "", false
+}
+
+// Checks if string can be result of stringifyKey() for key of other type than string (number or bool)
+func stringifiable(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch c := s[0]; {
+	case s == "true" || s == "false":
+		return true
+	case c >= '0' && c <= '9', c == '-', c == '+', c == 'N':
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	}
+	return false
+}
+
+func toDuration(value interface{}) (time.Duration, bool) {
+	switch d := value.(type) {
+	case time.Duration:
+		return d, true
+	case string:
+		if parsed, err := time.ParseDuration(d); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+// Replaces (in place) every string leaf of given tree with result of fn. Descends into maps (of both kinds) and slices.
+// Path of the leaf (as composite key) is passed to fn; processing stops on the first error
+func transformStrings(value interface{}, path, sep string, fn func(path, s string) (string, error)) (interface{}, error) {
+	return transformStringsDepth(value, path, sep, 0, fn)
+}
+
+func transformStringsDepth(value interface{}, path, sep string, depth int, fn func(path, s string) (string, error)) (interface{}, error) {
+	if _, isStr := value.(string); !isStr && depth > MaxDepth {
+		return nil, maxDepthErr(path)
+	}
+	switch node := value.(type) {
+	case string:
+		return fn(path, node)
+	case []interface{}:
+		for i, el := range node {
+			transformed, err := transformStringsDepth(el, joinKey(path, strconv.Itoa(i), sep), sep, depth+1, fn)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = transformed
+		}
+	case map[string]interface{}:
+		for k, el := range node {
+			transformed, err := transformStringsDepth(el, joinKey(path, k, sep), sep, depth+1, fn)
+			if err != nil {
+				return nil, err
 			}
+			node[k] = transformed
+		}
+	case map[interface{}]interface{}:
+		for k, el := range node {
+			transformed, err := transformStringsDepth(el, joinKey(path, fmt.Sprint(k), sep), sep, depth+1, fn)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = transformed
+		}
+	}
+	return value, nil
+}
+
+// Appends key part (escaping it, if needed) to composite key prefix
+func joinKey(prefix, key, sep string) string {
+	if prefix == "" {
+		return escapeKeyChunk(key, sep)
+	}
+	return prefix + sep + escapeKeyChunk(key, sep)
+}
+
+// Splits composite key to parts by sep. Separator, prefixed with backslash (like "\\."), is not treated as separator;
+// double backslash stands for literal backslash
+func splitKey(key, sep string) []string {
+	if !strings.Contains(key, `\`) {
+		return strings.Split(key, sep)
+	}
+	var chunks []string
+	var chunk strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key) && key[i+1] == '\\':
+			chunk.WriteByte('\\')
+			i++
+		case key[i] == '\\' && strings.HasPrefix(key[i+1:], sep):
+			chunk.WriteString(sep)
+			i += len(sep)
+		case strings.HasPrefix(key[i:], sep):
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+			i += len(sep) - 1
+		default:
+			chunk.WriteByte(key[i])
+		}
+	}
+	return append(chunks, chunk.String())
+}
+
+// Joins key parts to composite key, escaping them if needed (reverse to splitKey())
+func joinKeyChunks(chunks []string, sep string) string {
+	escaped := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		escaped[i] = escapeKeyChunk(chunk, sep)
+	}
+	return strings.Join(escaped, sep)
+}
+
+func escapeKeyChunk(chunk, sep string) string {
+	if !strings.Contains(chunk, `\`) && !strings.Contains(chunk, sep) {
+		return chunk
+	}
+	return strings.Replace(strings.Replace(chunk, `\`, `\\`, -1), sep, `\`+sep, -1)
+}
+
+// Calls fn for every node of given tree (except the root itself) in depth-first order, passing its path
+// (as composite key). Map keys are visited in sorted order; slices are descended with indices as path segments.
+// If fn returns SkipSubtree for a map or slice, its children are not visited; any other error stops the walk.
+// Nodes, nested deeper than MaxDepth, are not descended (ErrMaxDepthExceeded is returned)
+func walkTree(value interface{}, path, sep string, fn func(path string, v interface{}) error) error {
+	return walkTreeDepth(value, path, sep, 0, fn)
+}
+
+func walkTreeDepth(value interface{}, path, sep string, depth int, fn func(path string, v interface{}) error) error {
+	segments, values := childrenOf(value)
+	if len(values) > 0 && depth >= MaxDepth {
+		return maxDepthErr(path)
+	}
+	for i, child := range values {
+		childPath := joinKey(path, segments[i], sep)
+		if err := fn(childPath, child); err == SkipSubtree {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := walkTreeDepth(child, childPath, sep, depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func maxDepthErr(path string) error {
+	return fmt.Errorf("%w (%d) at '%s'", ErrMaxDepthExceeded, MaxDepth, path)
+}
+
+// Calls fn for every leaf of given tree in depth-first order, passing its path (as composite key).
+// Map keys are visited in sorted order. Slices are descended (with indices as path segments) only if descendSlices is true;
+// otherwise they are considered as leaves. Empty maps and slices are leaves too. Leaves, nested deeper than MaxDepth,
+// are not visited (ErrMaxDepthExceeded is returned)
+func walkLeaves(value interface{}, path, sep string, descendSlices bool, fn func(path string, v interface{})) error {
+	return walkTree(value, path, sep, func(path string, v interface{}) error {
+		if isLeaf(v, descendSlices) {
+			fn(path, v)
+			return SkipSubtree
+		}
+		return nil
+	})
+}
+
+func isLeaf(value interface{}, descendSlices bool) bool {
+	if m := toStrMap(value); m != nil {
+		return len(m) == 0
+	}
+	if a, ok := value.([]interface{}); ok {
+		return !descendSlices || len(a) == 0
+	}
+	return true
+}
+
+// Returns path segments and values of direct children of map (in sorted keys order) or slice (in ascending indices order)
+func childrenOf(node interface{}) ([]string, []interface{}) {
+	if a, ok := node.([]interface{}); ok {
+		segments := make([]string, len(a))
+		for i := range a {
+			segments[i] = strconv.Itoa(i)
+		}
+		return segments, a
+	}
+	m := toStrMap(node)
+	if m == nil {
+		return nil, nil
+	}
+	keys := mapSortedKeys(m)
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return keys, values
+}
+
+// Calls fn for every value of the tree, matching pattern, split to chunks. Chunk "*" matches any key of map
+// or any index of slice; chunk "**" matches any number (including zero) of nested levels. Values, nested
+// deeper than MaxDepth, are not descended by "**" (ErrMaxDepthExceeded is returned)
+func matchPattern(node interface{}, chunks []string, path string, f keyFormat, fn func(path string, v interface{})) error {
+	return matchPatternDepth(node, chunks, path, f, 0, fn)
+}
+
+func matchPatternDepth(node interface{}, chunks []string, path string, f keyFormat, depth int, fn func(path string, v interface{})) error {
+	if len(chunks) == 0 {
+		fn(path, node)
+		return nil
+	}
+	switch chunk := chunks[0]; chunk {
+	case "**":
+		if err := matchPatternDepth(node, chunks[1:], path, f, depth, fn); err != nil {
+			return err
+		}
+		segments, values := childrenOf(node)
+		if len(values) > 0 && depth >= MaxDepth {
+			return maxDepthErr(path)
+		}
+		for i, v := range values {
+			if err := matchPatternDepth(v, chunks, joinKey(path, segments[i], f.sep), f, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	case "*":
+		segments, values := childrenOf(node)
+		for i, v := range values {
+			if err := matchPatternDepth(v, chunks[1:], joinKey(path, segments[i], f.sep), f, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	default:
+		if v, err := getValueWithCompositeKey(node, chunks[:1], f); err == nil {
+			return matchPatternDepth(v, chunks[1:], joinKey(path, chunk, f.sep), f, depth+1, fn)
 		}
-		return strMap
 	}
 	return nil
 }
+
+// Calls fn for every value, found by key chunks. Unlike getValueWithCompositeKey(), when slice is met and current chunk
+// is not an index, remaining chunks are applied to every element of the slice (elements, where they don't resolve, are skipped)
+func pluck(node interface{}, chunks []string, path string, f keyFormat, fn func(path string, v interface{})) {
+	if len(chunks) == 0 {
+		fn(path, node)
+		return
+	}
+	if a, ok := node.([]interface{}); ok {
+		if _, err := strconv.Atoi(chunks[0]); err != nil {
+			for i, el := range a {
+				pluck(el, chunks, joinKey(path, strconv.Itoa(i), f.sep), f, fn)
+			}
+			return
+		}
+	}
+	if v, err := getValueWithCompositeKey(node, chunks[:1], f); err == nil {
+		pluck(v, chunks[1:], joinKey(path, chunks[0], f.sep), f, fn)
+	}
+}
@@ -0,0 +1,152 @@
+package conf8n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetAllRecursiveWildcardDepth(t *testing.T) {
+	defer func(depth int) { MaxDepth = depth }(MaxDepth)
+	MaxDepth = 5
+	nested := map[string]interface{}{"x": "deepest"}
+	for i := 0; i < 10; i++ {
+		nested = map[string]interface{}{"x": i, "n": nested}
+	}
+	c := NewConfig(map[string]interface{}{"n": nested})
+
+	values, err := c.GetAllE("**.x")
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded, got %v", err)
+	}
+	if len(values) == 0 || len(values) > MaxDepth+1 {
+		t.Errorf("Expected values above the limit, got %d", len(values))
+	}
+	if got := c.GetAll("**.x"); len(got) != len(values) {
+		t.Errorf("GetAll(): got %d values, want %d", len(got), len(values))
+	}
+	values, err = c.GetAllE("n.n.*")
+	if err != nil || len(values) != 2 {
+		t.Errorf("Pattern without \"**\": got %d values, %v", len(values), err)
+	}
+}
+
+func TestLookupDoesNotAllocate(t *testing.T) {
+	c := NewConfig(nestedTree(6, 4))
+	c.data["k0.k1"] = "literal"
+	// misses allocate errors, so only hits are checked
+	for _, key := range []string{nestedKey(1), nestedKey(3), nestedKey(6), "k0.k1"} {
+		if allocs := testing.AllocsPerRun(100, func() { c.lookup(key) }); allocs != 0 {
+			t.Errorf("Lookup of '%s': %v allocations", key, allocs)
+		}
+	}
+}
+
+func BenchmarkLookupSegments(b *testing.B) {
+	c := NewConfig(nestedTree(6, 4))
+	for _, segments := range []int{1, 3, 6} {
+		key := nestedKey(segments)
+		b.Run(fmt.Sprint(segments, "-segments"), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.lookup(key)
+			}
+		})
+	}
+}
+
+func selfReferencingTree() map[string]interface{} {
+	m := map[string]interface{}{"x": 1}
+	m["a"] = m
+	return m
+}
+
+// Trees with cycles must not overflow stack
+func TestSelfReferencingTree(t *testing.T) {
+	m := selfReferencingTree()
+	checks := map[string]func() error{
+		"deepCopy": func() error {
+			_, err := deepCopy(m)
+			return err
+		},
+		"mergeTrees": func() error {
+			_, err := mergeTrees(map[string]interface{}{"a": map[string]interface{}{"y": 2}}, m)
+			return err
+		},
+		"mergeInto": func() error {
+			return mergeInto(map[string]interface{}{"a": map[string]interface{}{"y": 2}}, m, nil)
+		},
+		"normalizeTree": func() error {
+			im := map[interface{}]interface{}{1: "x"}
+			im["a"] = []interface{}{im}
+			_, err := normalizeTree(im)
+			return err
+		},
+		"AllKeysE": func() error {
+			_, err := NewConfig(m).AllKeysE()
+			return err
+		},
+		"IterateLeavesE": func() error {
+			_, err := NewConfig(m).IterateLeavesE()
+			return err
+		},
+		"ToEnvE": func() error {
+			_, err := NewConfig(m).ToEnvE(EnvOptions{})
+			return err
+		},
+		"VerifyEnvMapping": func() error {
+			return NewConfig(m).VerifyEnvMapping(EnvOptions{})
+		},
+		"ProvenanceE": func() error {
+			_, err := NewConfig(m).ProvenanceE()
+			return err
+		},
+		"Migrate": func() error {
+			_, err := NewMigrations().Add(0, func(*Config) error { return nil }).Migrate(NewConfig(m), "version")
+			return err
+		},
+		"ProfileWith": func() error {
+			c := NewConfig(map[string]interface{}{"defaults": m, "prod": m})
+			_, err := c.ProfileWith("prod", ProfileOptions{})
+			return err
+		},
+	}
+	for name, check := range checks {
+		if err := check(); !errors.Is(err, ErrMaxDepthExceeded) {
+			t.Errorf("%s: expected ErrMaxDepthExceeded, got %v", name, err)
+		}
+	}
+
+	c := NewConfig(map[string]interface{}{"a": map[string]interface{}{"y": 2}})
+	c.Merge(NewConfig(m), "")
+	if c.Get("a.a.x").Int() != 1 || c.Get("a.y").Int() != 2 {
+		t.Errorf("Merge(): got %v, %v", c.Get("a.a.x").Raw(), c.Get("a.y").Raw())
+	}
+	overlay := NewConfig(m).Overlay(NewConfig(selfReferencingTree()))
+	if err := overlay.Set("a.a.x", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := m["a"].(map[string]interface{})["x"]; got != 1 {
+		t.Errorf("Change of overlay leaked into base: got %v", got)
+	}
+}
+
+func TestWalkLeavesDepth(t *testing.T) {
+	defer func(depth int) { MaxDepth = depth }(MaxDepth)
+	MaxDepth = 3
+	c := NewConfig(map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"d": 1}}},
+		"_": 1,
+	})
+	keys, err := c.AllKeysE()
+	if !errors.Is(err, ErrMaxDepthExceeded) || !strings.Contains(err.Error(), "'a.b.c'") {
+		t.Errorf("Expected ErrMaxDepthExceeded at 'a.b.c', got %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "_" {
+		t.Errorf("Keys, found before the limit, expected: got %v", keys)
+	}
+	if got := c.AllKeys(); len(got) != len(keys) {
+		t.Errorf("AllKeys(): got %v, want %v", got, keys)
+	}
+}
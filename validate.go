@@ -0,0 +1,193 @@
+package conf8n
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var validators = struct {
+	sync.RWMutex
+	m map[string]func(v *ConfigValue) error
+}{m: make(map[string]func(v *ConfigValue) error)}
+
+// Registers named validator, that can be used by schemas (see SchemaKey.Validate()) and in "validate" struct tags
+// (see ConfigValue.Decode()). Validator gets value of the key and returns error, if value is invalid.
+// Registering validator with the same name again replaces it. Safe for concurrent use
+func RegisterValidator(name string, fn func(v *ConfigValue) error) {
+	validators.Lock()
+	defer validators.Unlock()
+	validators.m[name] = fn
+}
+
+func lookupValidator(name string) (func(v *ConfigValue) error, bool) {
+	validators.RLock()
+	defer validators.RUnlock()
+	fn, found := validators.m[name]
+	return fn, found
+}
+
+// Severity of validation problem
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Problem, found by validation: path of the value (as composite key, empty for the whole config),
+// failed rule (like "required" or JSON Schema keyword "minimum"; may be empty), description,
+// location of the key in source data (if known) and severity (errors by default; see SchemaKey.Warn())
+type ValidationError struct {
+	Path     string
+	Keyword  string
+	Err      error
+	Source   *Source
+	Severity Severity
+}
+
+// List of problems, found by validation (sorted by paths). Returned by Require(), Schema.Validate(), Decode(), etc.
+// Use errors.As() to get it (or single ValidationError) from returned error
+type ValidationErrors []ValidationError
+
+// Returns description of the problem, prefixed with the path
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(root)"
+	}
+	prefix := fmt.Sprintf("Key '%s'", path)
+	if e.Severity == SeverityWarning {
+		prefix = "Warning: " + prefix
+	}
+	if e.Source != nil {
+		prefix += fmt.Sprintf(" (%s)", e.Source)
+	}
+	if e.Keyword == "" {
+		return fmt.Sprintf("%s: %v", prefix, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", prefix, e.Keyword, e.Err)
+}
+
+// Returns underlying error
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Returns description of all problems, one per line
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	lines := make([]string, len(e))
+	for i, p := range e {
+		lines[i] = "\t" + p.Error()
+	}
+	return fmt.Sprintf("%d problems found:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// Returns all problems (so errors.Is() and errors.As() check each of them)
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, p := range e {
+		errs[i] = p
+	}
+	return errs
+}
+
+// Returns problems with error severity (nil, if there are none)
+func (e ValidationErrors) Errors() ValidationErrors {
+	return e.filter(SeverityError)
+}
+
+// Returns problems with warning severity (nil, if there are none)
+func (e ValidationErrors) Warnings() ValidationErrors {
+	return e.filter(SeverityWarning)
+}
+
+func (e ValidationErrors) filter(severity Severity) ValidationErrors {
+	var found ValidationErrors
+	for _, p := range e {
+		if p.Severity == severity {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// Creates problem for the key of config, remembering its location
+func (c *Config) validationError(key, keyword string, err error) ValidationError {
+	problem := ValidationError{Path: key, Keyword: keyword, Err: err}
+	if src, ok := c.SourceOf(key); ok {
+		problem.Source = &src
+	}
+	return problem
+}
+
+// Returns problems, sorted by paths, as error (nil, if there are no problems)
+func (e ValidationErrors) result() error {
+	if len(e) == 0 {
+		return nil
+	}
+	sort.SliceStable(e, func(i, j int) bool {
+		return e[i].Path < e[j].Path
+	})
+	return e
+}
+
+// Checks, that every given key is set to non-null value. Reports all missing keys at once
+func (c *Config) Require(keys ...string) error {
+	var problems ValidationErrors
+	for _, key := range keys {
+		if v := c.Get(key); !v.IsSet() {
+			problems = append(problems, c.validationError(key, "required", v.notSetReason()))
+		}
+	}
+	return problems.result()
+}
+
+// Same as Require(), but also checks kinds of values (see ConfigValue.Kind()). Numbers match both Int and Float
+// kinds, if they can be converted without loss (so 5 loaded from JSON is a valid Int). Keys are checked in sorted order
+func (c *Config) RequireTyped(kinds map[string]ValueKind) error {
+	keys := make([]string, 0, len(kinds))
+	for key := range kinds {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var problems ValidationErrors
+	for _, key := range keys {
+		v := c.Get(key)
+		if !v.IsSet() {
+			problems = append(problems, c.validationError(key, "required", v.notSetReason()))
+		} else if !kindMatches(v.v, kinds[key]) {
+			problems = append(problems, c.validationError(key, "kind", kindErr(key, v.v, kinds[key])))
+		}
+	}
+	return problems.result()
+}
+
+func kindMatches(value interface{}, kind ValueKind) bool {
+	actual := kindOf(value)
+	if actual == kind {
+		return true
+	}
+	n, isNumber := toNumber(value)
+	switch {
+	case !isNumber:
+		return false
+	case kind == Float:
+		return actual == Int
+	case kind == Int:
+		return n == math.Trunc(n)
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package conf8n
+
+import (
+	"errors"
+	"sort"
+)
+
+// Can be returned by Walk() callback to skip children of current map or slice
+var SkipSubtree = errors.New("skip subtree")
+
+// Maximum nesting depth of maps and slices, processed by whole-tree operations (Walk(), ExpandEnv(), Resolve(), etc).
+// Protects from stack overflow on pathologically deep or cyclic data, built programmatically
+var MaxDepth = 1000
+
+// Returned (wrapped, with the path where limit was hit) when tree is nested deeper than MaxDepth;
+// check for it with errors.Is()
+var ErrMaxDepthExceeded = errors.New("Max depth exceeded")
+
+// Visits every value of config (sections, slices and leaves) in depth-first order, calling fn with path
+// of the value (as composite key; slice indices are used as key parts) and the value itself.
+// Keys of sections are visited in sorted order. Walk stops on the first error, returned by fn,
+// and returns it; SkipSubtree error skips children of current value. Walk fails with ErrMaxDepthExceeded
+// if config is nested deeper than MaxDepth
+func (c *Config) Walk(fn func(path string, v *ConfigValue) error) error {
+	return walkTree(c.tree(), "", c.separator(), func(path string, v interface{}) error {
+		return fn(path, &ConfigValue{v: v, c: c, key: path})
+	})
+}
+
+// Returns sorted paths (composite keys) of all leaf values of config, matching given predicate.
+// Search descends into sections and slices
+func (c *Config) Find(pred func(path string, v *ConfigValue) bool) []string {
+	var paths []string
+	c.Walk(func(path string, v *ConfigValue) error {
+		if isLeaf(v.v, true) && pred(path, v) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+// Returns sorted paths of all leaf values of config, equal to given one.
+// Numbers are compared regardless of their representation (so 5 matches both int 5 and float 5.0)
+func (c *Config) FindValue(want interface{}) []string {
+	return c.Find(func(_ string, v *ConfigValue) bool {
+		return looseEqual(v.v, want)
+	})
+}
+
+// Iterates over leaf values (scalars, empty maps and slices) of the tree; Key() returns path of the leaf
+type LeafIterator struct {
+	*ListIterator
+	paths []string
+}
+
+// Returns iterator over every leaf of config in depth-first order (the same, as order of Walk()).
+// Sections and slices are descended (slice indices are used as key parts). Leaves, nested deeper than MaxDepth,
+// are not visited (see IterateLeavesE())
+func (c *Config) IterateLeaves() Iterator {
+	it, _ := c.IterateLeavesE()
+	return it
+}
+
+// Same as IterateLeaves(), but reports error (wrapping ErrMaxDepthExceeded), if config is nested deeper than MaxDepth.
+// Iterator over leaves, found before that, is returned too
+func (c *Config) IterateLeavesE() (Iterator, error) {
+	it, err := (&ConfigValue{v: c.tree(), c: c}).iterateLeaves()
+	if leaves, ok := it.(*LeafIterator); ok {
+		leaves.keyed = true
+	}
+	return it, err
+}
+
+// Same as Config.IterateLeaves(), but iterates leaves of the value (paths are relative to it).
+// Gives EmptyIterator, if value is neither map nor slice
+func (v ConfigValue) IterateLeaves() Iterator {
+	it, _ := v.iterateLeaves()
+	return it
+}
+
+func (v ConfigValue) iterateLeaves() (Iterator, error) {
+	if kindOf(v.v) != Map && kindOf(v.v) != Slice {
+		return &EmptyIterator{}, nil
+	}
+	it := &LeafIterator{ListIterator: &ListIterator{c: v.c, key: v.key, keyed: v.key != ""}}
+	err := walkLeaves(v.v, "", v.c.separator(), true, func(path string, leaf interface{}) {
+		it.paths = append(it.paths, path)
+		it.a = append(it.a, leaf)
+	})
+	return it, err
+}
+
+// Returns path of current leaf
+func (i *LeafIterator) Key() string {
+	if i.Finished() {
+		return ""
+	}
+	return i.paths[i.i]
+}
+
+// Returns current leaf as Config (it could be empty map only, so error is reported in most cases)
+func (i *LeafIterator) Section() (*Config, error) {
+	return iteratorSection(i)
+}
+
+// See doc for Config.IterateLeaves()
+func (i *LeafIterator) Value() *ConfigValue {
+	v := i.ValueV()
+	return &v
+}
+
+// Same as Value(), but returns value itself (see ListIterator.ValueV()); its key is path of the leaf
+func (i *LeafIterator) ValueV() ConfigValue {
+	if i.Finished() {
+		return ConfigValue{c: i.c}
+	}
+	value := ConfigValue{v: i.a[i.i], c: i.c}
+	switch {
+	case i.key != "":
+		value.key = i.key + i.c.separator() + i.paths[i.i]
+	case i.keyed:
+		value.key = i.paths[i.i]
+	}
+	return value
+}
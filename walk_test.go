@@ -0,0 +1,35 @@
+package conf8n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIterateLeavesKeys(t *testing.T) {
+	c := NewConfig(map[string]interface{}{
+		"db":      map[string]interface{}{"host": "db.local", "port": 5432},
+		"servers": []interface{}{map[string]interface{}{"name": "a"}, "b"},
+	})
+	tests := []struct {
+		it   Iterator
+		want []string
+	}{
+		{c.IterateLeaves(), []string{"db.host", "db.port", "servers.0.name", "servers.1"}},
+		{c.Get("servers").IterateLeaves(), []string{"servers.0.name", "servers.1"}},
+		{(&ConfigValue{v: c.tree()["db"], c: c}).IterateLeaves(), []string{"", ""}},
+	}
+	for i, tt := range tests {
+		var keys, valueKeys, valueVKeys []string
+		for it := tt.it; !it.Finished(); it.Next() {
+			keys = append(keys, it.Key())
+			valueKeys = append(valueKeys, it.Value().Key())
+			valueVKeys = append(valueVKeys, it.(*LeafIterator).ValueV().Key())
+		}
+		if !reflect.DeepEqual(valueKeys, tt.want) || !reflect.DeepEqual(valueVKeys, tt.want) {
+			t.Errorf("Case %d: got keys of values %v (Value()) and %v (ValueV()), want %v", i, valueKeys, valueVKeys, tt.want)
+		}
+		if len(keys) != len(tt.want) {
+			t.Errorf("Case %d: got %d leaves, want %d", i, len(keys), len(tt.want))
+		}
+	}
+}
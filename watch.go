@@ -0,0 +1,231 @@
+package conf8n
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Delay between the last change of watched file and its reloading (see WatchFile()). Series of changes,
+// made within this delay (like editor's save, done as several writes), cause single reload
+var WatchDebounce = 100 * time.Millisecond
+
+// Source of file system events for Watcher. Default implementation is based on fsnotify; it can be replaced
+// (see WatchFileWith()), for example, with stub in tests
+type FileNotifier interface {
+	// Starts watching of the directory
+	Add(dir string) error
+	// Returns channel of paths of created, changed, renamed or removed files of watched directories
+	Events() <-chan string
+	// Returns channel of watching errors
+	Errors() <-chan error
+	// Stops watching
+	Close() error
+}
+
+// Watcher of config source, reloading config on its changes (see WatchFile() and WatchURL())
+type Watcher struct {
+	onChange func(c *Config, err error)
+	cancel   context.CancelFunc
+	done     chan struct{}
+	mu       sync.RWMutex
+	current  *Config
+}
+
+// Creates watcher with initially loaded config. Returned context is canceled, when watching should stop
+func newWatcher(ctx context.Context, c *Config, onChange func(c *Config, err error)) (*Watcher, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Watcher{onChange: onChange, cancel: cancel, done: make(chan struct{}), current: c}, ctx
+}
+
+// Returns the last successfully loaded config
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Stops watching. Reload, that is in progress, is completed (and its callback is called) before the watching stops
+func (w *Watcher) Close() error {
+	w.cancel()
+	return nil
+}
+
+// Returns channel, which is closed, when watching is stopped
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Makes reloaded config current and reports it
+func (w *Watcher) update(c *Config) {
+	w.mu.Lock()
+	w.current = c
+	w.mu.Unlock()
+	w.onChange(c, nil)
+}
+
+// Loads config from file (see NewConfigFromFile()) and starts watching it. On every change of the file config is
+// reloaded with the same options and onChange is called with the new config. If reloading fails, onChange is called
+// with the error, and the previous config stays active (see Watcher.Config()). Replacing of the file (editors save
+// files by writing a new one and renaming it; Kubernetes updates mounted volumes by switching symlinks) is handled
+// as change. Watching stops, when context is canceled or Watcher.Close() is called.
+// Callbacks are called sequentially from the watching goroutine
+func WatchFile(ctx context.Context, filename string, onChange func(c *Config, err error), opts ...LoadOption) (*Watcher, error) {
+	n, err := newFsNotifier()
+	if err != nil {
+		return nil, err
+	}
+	w, err := WatchFileWith(ctx, n, filename, onChange, opts...)
+	if err != nil {
+		n.Close()
+	}
+	return w, err
+}
+
+// Same as WatchFile(), but file system events are taken from given notifier, which is closed, when watching stops
+func WatchFileWith(ctx context.Context, n FileNotifier, filename string, onChange func(c *Config, err error), opts ...LoadOption) (*Watcher, error) {
+	c, err := NewConfigFromFile(filename, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// directory is watched, as the file itself may be replaced
+	if err := n.Add(filepath.Dir(filename)); err != nil {
+		return nil, err
+	}
+	w, ctx := newWatcher(ctx, c, onChange)
+	fw := &fileWatch{w: w, filename: filepath.Clean(filename), opts: opts, notifier: n}
+	fw.realPath, _ = filepath.EvalSymlinks(filename)
+	go fw.run(ctx)
+	return w, nil
+}
+
+// State of file watching
+type fileWatch struct {
+	w        *Watcher
+	filename string
+	realPath string
+	opts     []LoadOption
+	notifier FileNotifier
+}
+
+func (fw *fileWatch) run(ctx context.Context) {
+	defer close(fw.w.done)
+	defer fw.notifier.Close()
+	var timer *time.Timer
+	var fire <-chan time.Time
+	events, errs := fw.notifier.Events(), fw.notifier.Errors()
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case path, ok := <-events:
+			if !ok {
+				return
+			}
+			if !fw.affected(path) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(WatchDebounce)
+			} else {
+				if !timer.Stop() && fire != nil {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(WatchDebounce)
+			}
+			fire = timer.C
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			fw.w.onChange(nil, err)
+		case <-fire:
+			fire = nil
+			fw.reload()
+		}
+	}
+}
+
+// Checks, if event on given path may change the watched file
+func (fw *fileWatch) affected(path string) bool {
+	if filepath.Clean(path) == fw.filename {
+		return true
+	}
+	// symlink (or some of its parents, like Kubernetes' "..data") was switched to another target
+	realPath, err := filepath.EvalSymlinks(fw.filename)
+	if err == nil && realPath != fw.realPath {
+		fw.realPath = realPath
+		return true
+	}
+	return false
+}
+
+func (fw *fileWatch) reload() {
+	c, err := NewConfigFromFile(fw.filename, fw.opts...)
+	if err != nil {
+		fw.w.onChange(nil, err)
+		return
+	}
+	fw.w.update(c)
+}
+
+// FileNotifier, based on fsnotify
+type fsNotifier struct {
+	w      *fsnotify.Watcher
+	events chan string
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newFsNotifier() (*fsNotifier, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	n := &fsNotifier{w: w, events: make(chan string), done: make(chan struct{})}
+	go n.run()
+	return n, nil
+}
+
+// Passes events (except changes of attributes) to events channel
+func (n *fsNotifier) run() {
+	defer close(n.events)
+	for ev := range n.w.Events {
+		if ev.Op == fsnotify.Chmod {
+			continue
+		}
+		select {
+		case n.events <- ev.Name:
+		case <-n.done:
+			return
+		}
+	}
+}
+
+func (n *fsNotifier) Add(dir string) error {
+	return n.w.Add(dir)
+}
+
+func (n *fsNotifier) Events() <-chan string {
+	return n.events
+}
+
+func (n *fsNotifier) Errors() <-chan error {
+	return n.w.Errors
+}
+
+func (n *fsNotifier) Close() error {
+	n.once.Do(func() {
+		close(n.done)
+	})
+	return n.w.Close()
+}
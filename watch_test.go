@@ -0,0 +1,273 @@
+package conf8n
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FileNotifier, driven by test
+type stubNotifier struct {
+	events chan string
+	errs   chan error
+	mu     sync.Mutex
+	dirs   []string
+	closed bool
+}
+
+func newStubNotifier() *stubNotifier {
+	return &stubNotifier{events: make(chan string), errs: make(chan error)}
+}
+
+func (n *stubNotifier) Add(dir string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dirs = append(n.dirs, dir)
+	return nil
+}
+
+func (n *stubNotifier) Events() <-chan string {
+	return n.events
+}
+
+func (n *stubNotifier) Errors() <-chan error {
+	return n.errs
+}
+
+func (n *stubNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	return nil
+}
+
+func (n *stubNotifier) isClosed() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.closed
+}
+
+// Result of onChange callback
+type watchResult struct {
+	c   *Config
+	err error
+}
+
+// Starts watching of file with given content by stub notifier. Results of callbacks are sent to returned channel
+func startStubWatch(t *testing.T, content string) (*Watcher, *stubNotifier, string, chan watchResult) {
+	dir, err := ioutil.TempDir("", "conf8n-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	filename := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, filename, content)
+	n := newStubNotifier()
+	results := make(chan watchResult, 10)
+	w, err := WatchFileWith(context.Background(), n, filename, func(c *Config, err error) {
+		results <- watchResult{c, err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w, n, filename, results
+}
+
+func writeTestFile(t *testing.T, filename, content string) {
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nextResult(t *testing.T, results chan watchResult) watchResult {
+	select {
+	case res := <-results:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("Callback was not called")
+	}
+	return watchResult{}
+}
+
+func expectNoResult(t *testing.T, results chan watchResult) {
+	select {
+	case res := <-results:
+		t.Errorf("Unexpected callback call with %v, %v", res.c, res.err)
+	case <-time.After(5 * WatchDebounce):
+	}
+}
+
+func withWatchDebounce(d time.Duration) func() {
+	prev := WatchDebounce
+	WatchDebounce = d
+	return func() { WatchDebounce = prev }
+}
+
+func TestWatchFileDebounce(t *testing.T) {
+	defer withWatchDebounce(20 * time.Millisecond)()
+	w, n, filename, results := startStubWatch(t, "v: 1\n")
+	if len(n.dirs) != 1 || n.dirs[0] != filepath.Dir(filename) {
+		t.Errorf("Directory of file is expected to be watched, got %v", n.dirs)
+	}
+	writeTestFile(t, filename, "v: 2\n")
+	for i := 0; i < 5; i++ {
+		n.events <- filename
+	}
+	// events of other files of the directory are ignored
+	n.events <- filepath.Join(filepath.Dir(filename), "other.yaml")
+	res := nextResult(t, results)
+	if res.err != nil || res.c.Get("v").Int() != 2 {
+		t.Fatalf("Got %v, %v", res.c, res.err)
+	}
+	expectNoResult(t, results)
+	if w.Config() != res.c {
+		t.Errorf("Reloaded config is expected to be current")
+	}
+}
+
+func TestWatchFileReplacedByRename(t *testing.T) {
+	defer withWatchDebounce(10 * time.Millisecond)()
+	w, n, filename, results := startStubWatch(t, "v: 1\n")
+	tmp := filename + ".tmp"
+	writeTestFile(t, tmp, "v: 2\n")
+	if err := os.Rename(tmp, filename); err != nil {
+		t.Fatal(err)
+	}
+	n.events <- tmp
+	n.events <- filename
+	if res := nextResult(t, results); res.err != nil || res.c.Get("v").Int() != 2 {
+		t.Fatalf("Got %v, %v", res.c, res.err)
+	}
+	// file is removed and created again
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	n.events <- filename
+	writeTestFile(t, filename, "v: 3\n")
+	n.events <- filename
+	if res := nextResult(t, results); res.err != nil || res.c.Get("v").Int() != 3 {
+		t.Fatalf("Got %v, %v", res.c, res.err)
+	}
+	if got := w.Config().Get("v").Int(); got != 3 {
+		t.Errorf("Got %d", got)
+	}
+}
+
+func TestWatchFileSymlinkSwap(t *testing.T) {
+	defer withWatchDebounce(10 * time.Millisecond)()
+	dir, err := ioutil.TempDir("", "conf8n-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// layout of Kubernetes' volumes: config.yaml -> ..data/config.yaml, ..data -> ..v1
+	for _, v := range []string{"..v1", "..v2"} {
+		if err := os.Mkdir(filepath.Join(dir, v), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(dir, v, "config.yaml"), "v: "+v+"\n")
+	}
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+	filename := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), filename); err != nil {
+		t.Fatal(err)
+	}
+	n := newStubNotifier()
+	results := make(chan watchResult, 10)
+	w, err := WatchFileWith(context.Background(), n, filename, func(c *Config, err error) {
+		results <- watchResult{c, err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// events of unrelated files don't cause reloading, while symlink is not switched
+	n.events <- filepath.Join(dir, "..data_tmp")
+	expectNoResult(t, results)
+
+	if err := os.Symlink("..v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	n.events <- filepath.Join(dir, "..data")
+	if res := nextResult(t, results); res.err != nil || res.c.Get("v").String() != "..v2" {
+		t.Fatalf("Got %v, %v", res.c, res.err)
+	}
+}
+
+func TestWatchFileKeepsConfigOnErrors(t *testing.T) {
+	defer withWatchDebounce(10 * time.Millisecond)()
+	w, n, filename, results := startStubWatch(t, "v: 1\n")
+	initial := w.Config()
+	writeTestFile(t, filename, "v: [1\n")
+	n.events <- filename
+	if res := nextResult(t, results); res.err == nil || res.c != nil {
+		t.Errorf("Parse error is expected, got %v, %v", res.c, res.err)
+	}
+	notifierErr := errors.New("queue overflow")
+	n.errs <- notifierErr
+	if res := nextResult(t, results); !errors.Is(res.err, notifierErr) {
+		t.Errorf("Error of notifier is expected, got %v", res.err)
+	}
+	if w.Config() != initial {
+		t.Errorf("Previous config is expected to stay active")
+	}
+	writeTestFile(t, filename, "v: 2\n")
+	n.events <- filename
+	if res := nextResult(t, results); res.err != nil || res.c.Get("v").Int() != 2 {
+		t.Errorf("Got %v, %v", res.c, res.err)
+	}
+}
+
+func TestWatchFileClose(t *testing.T) {
+	stops := map[string]func(w *Watcher, n *stubNotifier, cancel context.CancelFunc){
+		"Close":         func(w *Watcher, _ *stubNotifier, _ context.CancelFunc) { w.Close() },
+		"context":       func(_ *Watcher, _ *stubNotifier, cancel context.CancelFunc) { cancel() },
+		"closed events": func(_ *Watcher, n *stubNotifier, _ context.CancelFunc) { close(n.events) },
+	}
+	for name, stop := range stops {
+		dir, err := ioutil.TempDir("", "conf8n-watch")
+		if err != nil {
+			t.Fatal(err)
+		}
+		filename := filepath.Join(dir, "config.yaml")
+		writeTestFile(t, filename, "v: 1\n")
+		n := newStubNotifier()
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := WatchFileWith(ctx, n, filename, func(*Config, error) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stop(w, n, cancel)
+		select {
+		case <-w.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: watching is not stopped", name)
+		}
+		if !n.isClosed() {
+			t.Errorf("%s: notifier is expected to be closed", name)
+		}
+		cancel()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWatchFileInitialErrors(t *testing.T) {
+	n := newStubNotifier()
+	if _, err := WatchFileWith(context.Background(), n, filepath.Join(os.TempDir(), "conf8n-missing.yaml"), func(*Config, error) {}); err == nil {
+		t.Errorf("Missing file is expected to be reported")
+	}
+	if len(n.dirs) != 0 {
+		t.Errorf("Nothing is expected to be watched, got %v", n.dirs)
+	}
+}